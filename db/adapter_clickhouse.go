@@ -0,0 +1,21 @@
+//go:build !no_clickhouse && (all_db || clickhouse || !no_default_db)
+
+package db
+
+import (
+	"gorm.io/driver/clickhouse"
+	"gorm.io/gorm"
+)
+
+// errClickHouseDriver is nil because ClickHouse support is compiled in
+var errClickHouseDriver error
+
+// newClickHouseDialector creates a ClickHouse dialector from the given DSN
+func newClickHouseDialector(dsn string) (gorm.Dialector, error) {
+	return clickhouse.Open(dsn), nil
+}
+
+// newClickHouseConnDialector creates a ClickHouse dialector that reuses an open connection pool
+func newClickHouseConnDialector(conn gorm.ConnPool) (gorm.Dialector, error) {
+	return clickhouse.New(clickhouse.Config{Conn: conn}), nil
+}
@@ -0,0 +1,20 @@
+//go:build no_clickhouse || (!all_db && !clickhouse && no_default_db)
+
+package db
+
+import (
+	"gorm.io/gorm"
+)
+
+// errClickHouseDriver reports that ClickHouse support is disabled by build tags
+var errClickHouseDriver = driverNotCompiled("ClickHouse", "clickhouse")
+
+// newClickHouseDialector is a stub that returns an error when ClickHouse support is disabled
+func newClickHouseDialector(dsn string) (gorm.Dialector, error) {
+	return nil, errClickHouseDriver
+}
+
+// newClickHouseConnDialector is a stub that returns an error when ClickHouse support is disabled
+func newClickHouseConnDialector(conn gorm.ConnPool) (gorm.Dialector, error) {
+	return nil, errClickHouseDriver
+}
@@ -0,0 +1,22 @@
+//go:build no_clickhouse || (!all_db && !clickhouse && no_default_db)
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClickHouseStub(t *testing.T) {
+	assert.NotContains(t, SupportedTypes(), ClickHouse)
+
+	_, err := NewGORMManagerFromConfig(ManagerConfig{Primary: DBConfig{Type: ClickHouse, Host: "localhost", Database: "app"}})
+	assert.ErrorIs(t, err, ErrDriverNotCompiled)
+
+	_, err = createDialector(DBConfig{Type: ClickHouse, DSN: "clickhouse://default@localhost:9000/app"})
+	assert.ErrorIs(t, err, ErrDriverNotCompiled)
+
+	err = ManagerConfig{Primary: DBConfig{Type: ClickHouse, Host: "localhost", Database: "app"}}.Validate()
+	assert.ErrorContains(t, err, "primary.type: db: driver not compiled in: ClickHouse support not compiled in")
+}
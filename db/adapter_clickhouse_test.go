@@ -0,0 +1,47 @@
+//go:build !no_clickhouse && (all_db || clickhouse || !no_default_db)
+
+package db
+
+import (
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDSN_ClickHouseRoundTrip(t *testing.T) {
+	config := DBConfig{
+		Type:     ClickHouse,
+		Host:     "ch.internal",
+		Username: "app",
+		Password: "p@ss w:rd/?#",
+		Database: "events",
+		Params:   "dial_timeout=5s",
+		TLS:      TLSConfig{Mode: TLSRequire},
+	}
+	dsn, err := config.BuildDSN()
+	require.NoError(t, err)
+
+	parsed, err := clickhouse.ParseDSN(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ch.internal:9000"}, parsed.Addr)
+	assert.Equal(t, clickhouse.Auth{Database: "events", Username: "app", Password: "p@ss w:rd/?#"}, parsed.Auth)
+	require.NotNil(t, parsed.TLS)
+	assert.True(t, parsed.TLS.InsecureSkipVerify)
+
+	config.TLS = TLSConfig{Mode: TLSVerifyFull}
+	dsn, err = config.BuildDSN()
+	require.NoError(t, err)
+	parsed, err = clickhouse.ParseDSN(dsn)
+	require.NoError(t, err)
+	require.NotNil(t, parsed.TLS)
+	assert.False(t, parsed.TLS.InsecureSkipVerify)
+
+	config.TLS = TLSConfig{}
+	dsn, err = config.BuildDSN()
+	require.NoError(t, err)
+	parsed, err = clickhouse.ParseDSN(dsn)
+	require.NoError(t, err)
+	assert.Nil(t, parsed.TLS)
+}
@@ -3,11 +3,143 @@
 package db
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
+// errMySQLDriver is nil because MySQL support is compiled in
+var errMySQLDriver error
+
 // newMySQLDialector creates a MySQL dialector from the given DSN
-func newMySQLDialector(dsn string) gorm.Dialector {
-	return mysql.Open(dsn)
+func newMySQLDialector(dsn string) (gorm.Dialector, error) {
+	return mysql.Open(dsn), nil
+}
+
+// newMySQLConnDialector creates a MySQL dialector that reuses an open connection pool
+func newMySQLConnDialector(conn gorm.ConnPool) (gorm.Dialector, error) {
+	return mysql.New(mysql.Config{Conn: conn}), nil
+}
+
+// isMySQLRetryable reports whether err is a deadlock (1213) or a lock wait timeout (1205)
+func isMySQLRetryable(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	return mysqlErr.Number == 1213 || mysqlErr.Number == 1205
+}
+
+// isMySQLSyntaxError reports whether err is a syntax error (1064), as returned
+// for statements the server does not support
+func isMySQLSyntaxError(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1064
+}
+
+// formatMySQLDSN formats the config as a MySQL DSN for addr with the driver's
+// Config.FormatDSN, which escapes the credentials. Params are parsed by the
+// driver first so that invalid params are reported here rather than on open.
+// Certificate files are loaded into a tls.Config registered with the driver.
+func formatMySQLDSN(c *DBConfig, addr string) (string, error) {
+	// The DSN format splits the user and password at the first ':'
+	if strings.Contains(c.Username, ":") {
+		return "", errors.New("MySQL username must not contain ':'")
+	}
+	cfg := mysqldriver.NewConfig()
+	if c.Params != "" {
+		parsed, err := mysqldriver.ParseDSN("/?" + c.Params)
+		if err != nil {
+			return "", fmt.Errorf("invalid params: %w", err)
+		}
+		cfg = parsed
+	}
+	cfg.User = c.Username
+	cfg.Passwd = c.Password
+	cfg.Net = "tcp"
+	cfg.Addr = addr
+	cfg.DBName = c.Database
+
+	switch {
+	case c.TLS.Mode == TLSDisable:
+		cfg.TLSConfig = "false"
+	case c.TLS.Mode == TLSVerifyCA || c.TLS.hasFiles():
+		name, err := registerMySQLTLSConfig(c.TLS, c.Host, addr)
+		if err != nil {
+			return "", err
+		}
+		cfg.TLSConfig = name
+		// The registered config skips verification in prefer mode, and the
+		// driver falls back to plaintext when the server does not support TLS
+		cfg.AllowFallbackToPlaintext = c.TLS.Mode == TLSPrefer
+	case c.TLS.Mode == TLSPrefer:
+		cfg.TLSConfig = "preferred"
+	case c.TLS.Mode == TLSRequire:
+		cfg.TLSConfig = "skip-verify"
+	case c.TLS.Mode == TLSVerifyFull:
+		cfg.TLSConfig = "true"
+	}
+	// The parsed tls.Config would take priority over TLSConfig when formatting
+	cfg.TLS = nil
+
+	return cfg.FormatDSN(), nil
+}
+
+// mysqlTLSConfigs holds the names of the tls.Configs registered with the driver
+var mysqlTLSConfigs sync.Map
+
+// registerMySQLTLSConfig registers the tls.Config for the TLS settings and host
+// with the driver once and returns its name. The certificate files are read on
+// the first registration only, so a rotated certificate needs a new file name.
+func registerMySQLTLSConfig(t TLSConfig, host, addr string) (string, error) {
+	name := t.registrationName(addr)
+	if _, ok := mysqlTLSConfigs.Load(name); ok {
+		return name, nil
+	}
+	tlsConfig, err := t.clientConfig(host)
+	if err != nil {
+		return "", err
+	}
+	if err := mysqldriver.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return "", fmt.Errorf("failed to register TLS config: %w", err)
+	}
+	mysqlTLSConfigs.Store(name, struct{}{})
+	return name, nil
+}
+
+// parseMySQLDSN parses a MySQL DSN with the driver's ParseDSN. The parameters
+// other than tls are formatted back into Params by the driver.
+func parseMySQLDSN(dsn string) (DBConfig, error) {
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		return DBConfig{}, fmt.Errorf("invalid MySQL DSN: %w", err)
+	}
+	if cfg.Net != "tcp" {
+		return DBConfig{}, fmt.Errorf("unsupported MySQL DSN network: %s", cfg.Net)
+	}
+	host, port, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return DBConfig{}, fmt.Errorf("invalid MySQL DSN address: %w", err)
+	}
+
+	config := DBConfig{Type: MySQL, Host: host, Username: cfg.User, Password: cfg.Passwd, Database: cfg.DBName}
+	if config.Port, err = strconv.Atoi(port); err != nil {
+		return DBConfig{}, fmt.Errorf("invalid MySQL DSN port: %q", port)
+	}
+	if mode, ok := mysqlTLSModes[cfg.TLSConfig]; ok {
+		// Custom tls.Config names stay in Params
+		config.TLS.Mode = mode
+		cfg.TLSConfig = ""
+	}
+
+	cfg.User, cfg.Passwd, cfg.Net, cfg.Addr, cfg.DBName, cfg.TLS = "", "", "", "", "", nil
+	config.Params = strings.TrimPrefix(strings.TrimPrefix(cfg.FormatDSN(), "/"), "?")
+	return config, nil
 }
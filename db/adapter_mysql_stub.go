@@ -3,12 +3,38 @@
 package db
 
 import (
-	"fmt"
-
 	"gorm.io/gorm"
 )
 
+// errMySQLDriver reports that MySQL support is disabled by build tags
+var errMySQLDriver = driverNotCompiled("MySQL", "mysql")
+
 // newMySQLDialector is a stub that returns an error when MySQL support is disabled
-func newMySQLDialector(dsn string) gorm.Dialector {
-	panic(fmt.Errorf("MySQL support not compiled in. Build with -tags mysql to enable"))
+func newMySQLDialector(dsn string) (gorm.Dialector, error) {
+	return nil, errMySQLDriver
+}
+
+// newMySQLConnDialector is a stub that returns an error when MySQL support is disabled
+func newMySQLConnDialector(conn gorm.ConnPool) (gorm.Dialector, error) {
+	return nil, errMySQLDriver
+}
+
+// isMySQLRetryable is a stub that reports no error as retryable when MySQL support is disabled
+func isMySQLRetryable(err error) bool {
+	return false
+}
+
+// isMySQLSyntaxError is a stub that reports no error as a syntax error when MySQL support is disabled
+func isMySQLSyntaxError(err error) bool {
+	return false
+}
+
+// formatMySQLDSN is a stub that returns an error when MySQL support is disabled
+func formatMySQLDSN(c *DBConfig, addr string) (string, error) {
+	return "", errMySQLDriver
+}
+
+// parseMySQLDSN is a stub that returns an error when MySQL support is disabled
+func parseMySQLDSN(dsn string) (DBConfig, error) {
+	return DBConfig{}, errMySQLDriver
 }
@@ -0,0 +1,22 @@
+//go:build no_mysql || (!all_db && !mysql && no_default_db)
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMySQLStub(t *testing.T) {
+	assert.NotContains(t, SupportedTypes(), MySQL)
+
+	_, err := NewGORMManagerFromConfig(ManagerConfig{Primary: DBConfig{Type: MySQL, Host: "localhost", Database: "app"}})
+	assert.ErrorIs(t, err, ErrDriverNotCompiled)
+
+	_, err = ParseDSN(MySQL, "root@tcp(localhost:3306)/app")
+	assert.ErrorIs(t, err, ErrDriverNotCompiled)
+
+	err = ManagerConfig{Primary: DBConfig{Type: MySQL, Host: "localhost", Database: "app"}}.Validate()
+	assert.ErrorContains(t, err, "primary.type: db: driver not compiled in: MySQL support not compiled in")
+}
@@ -3,11 +3,31 @@
 package db
 
 import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// errPostgreSQLDriver is nil because PostgreSQL support is compiled in
+var errPostgreSQLDriver error
+
 // newPostgreSQLDialector creates a PostgreSQL dialector from the given DSN
-func newPostgreSQLDialector(dsn string) gorm.Dialector {
-	return postgres.Open(dsn)
+func newPostgreSQLDialector(dsn string) (gorm.Dialector, error) {
+	return postgres.Open(dsn), nil
+}
+
+// newPostgreSQLConnDialector creates a PostgreSQL dialector that reuses an open connection pool
+func newPostgreSQLConnDialector(conn gorm.ConnPool) (gorm.Dialector, error) {
+	return postgres.New(postgres.Config{Conn: conn}), nil
+}
+
+// isPostgreSQLRetryable reports whether err is a serialization failure (40001) or a deadlock (40P01)
+func isPostgreSQLRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
 }
@@ -3,12 +3,23 @@
 package db
 
 import (
-	"fmt"
-
 	"gorm.io/gorm"
 )
 
+// errPostgreSQLDriver reports that PostgreSQL support is disabled by build tags
+var errPostgreSQLDriver = driverNotCompiled("PostgreSQL", "postgres")
+
 // newPostgreSQLDialector is a stub that returns an error when PostgreSQL support is disabled
-func newPostgreSQLDialector(dsn string) gorm.Dialector {
-	panic(fmt.Errorf("PostgreSQL support not compiled in. Build with -tags postgres to enable"))
+func newPostgreSQLDialector(dsn string) (gorm.Dialector, error) {
+	return nil, errPostgreSQLDriver
+}
+
+// newPostgreSQLConnDialector is a stub that returns an error when PostgreSQL support is disabled
+func newPostgreSQLConnDialector(conn gorm.ConnPool) (gorm.Dialector, error) {
+	return nil, errPostgreSQLDriver
+}
+
+// isPostgreSQLRetryable is a stub that reports no error as retryable when PostgreSQL support is disabled
+func isPostgreSQLRetryable(err error) bool {
+	return false
 }
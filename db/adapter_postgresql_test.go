@@ -0,0 +1,107 @@
+//go:build !no_postgres && (all_db || postgres || postgresql || !no_default_db)
+
+package db
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPostgreSQLRetryable(t *testing.T) {
+	assert.True(t, isRetryableError(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, isRetryableError(fmt.Errorf("commit: %w", &pgconn.PgError{Code: "40P01"})))
+	assert.False(t, isRetryableError(&pgconn.PgError{Code: "23505"}))
+}
+
+func TestBuildDSN_PostgreSQLRoundTrip(t *testing.T) {
+	config := DBConfig{
+		Type:     PostgreSQL,
+		Host:     "db.internal",
+		Port:     5433,
+		Username: "app user",
+		Password: "p@ss w:rd/?#'",
+		Database: "orders",
+		Params:   "application_name=gao connect_timeout=5",
+		TLS: TLSConfig{
+			Mode:     TLSVerifyFull,
+			CAFile:   "/etc/ssl/ca.pem",
+			CertFile: "/etc/ssl/client.pem",
+			KeyFile:  "/etc/ssl/client.key",
+		},
+	}
+	dsn, err := config.BuildDSN()
+	require.NoError(t, err)
+	assert.Contains(t, dsn, "sslmode=verify-full")
+	assert.Contains(t, dsn, "sslrootcert=%2Fetc%2Fssl%2Fca.pem")
+	assert.Contains(t, dsn, "sslcert=%2Fetc%2Fssl%2Fclient.pem")
+	assert.Contains(t, dsn, "sslkey=%2Fetc%2Fssl%2Fclient.key")
+
+	// Parse without the certificate files, which pgconn loads while parsing
+	config.TLS = TLSConfig{Mode: TLSDisable}
+	dsn, err = config.BuildDSN()
+	require.NoError(t, err)
+
+	parsed, err := pgconn.ParseConfig(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "db.internal", parsed.Host)
+	assert.Equal(t, uint16(5433), parsed.Port)
+	assert.Equal(t, "app user", parsed.User)
+	assert.Equal(t, "p@ss w:rd/?#'", parsed.Password)
+	assert.Equal(t, "orders", parsed.Database)
+	assert.Equal(t, "gao", parsed.RuntimeParams["application_name"])
+	assert.Equal(t, 5*time.Second, parsed.ConnectTimeout)
+	assert.Nil(t, parsed.TLSConfig)
+}
+
+func TestBuildDSN_PostgreSQLUnixSocket(t *testing.T) {
+	config := DBConfig{
+		Type:     PostgreSQL,
+		Host:     "/var/run/postgresql",
+		Username: "app",
+		Password: "secret",
+		Database: "orders",
+		TLS:      TLSConfig{Mode: TLSDisable},
+	}
+	dsn, err := config.BuildDSN()
+	require.NoError(t, err)
+	assert.NotContains(t, dsn, "%2Fvar%2Frun%2Fpostgresql:", "the socket is not the URL host")
+
+	parsed, err := pgconn.ParseConfig(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "/var/run/postgresql", parsed.Host)
+	assert.Equal(t, uint16(5432), parsed.Port)
+	assert.Equal(t, "orders", parsed.Database)
+
+	roundTrip, err := ParseDSN(PostgreSQL, dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "/var/run/postgresql", roundTrip.Host)
+	assert.Equal(t, 5432, roundTrip.Port)
+	assert.Equal(t, "secret", roundTrip.Password)
+	assert.Empty(t, roundTrip.Params)
+	assert.Equal(t, "postgres://app:xxxxx@/orders?host=%2Fvar%2Frun%2Fpostgresql&port=5432&sslmode=disable", roundTrip.Redacted())
+}
+
+func TestBuildDSN_PostgreSQLQuotedParams(t *testing.T) {
+	config := DBConfig{
+		Type:     PostgreSQL,
+		Host:     "db.internal",
+		Database: "orders",
+		Params:   `options='-c search_path=x' application_name='it\'s gao'`,
+	}
+	dsn, err := config.BuildDSN()
+	require.NoError(t, err)
+
+	parsed, err := pgconn.ParseConfig(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "-c search_path=x", parsed.RuntimeParams["options"])
+	assert.Equal(t, "it's gao", parsed.RuntimeParams["application_name"])
+	assert.NotContains(t, parsed.RuntimeParams, "search_path")
+
+	_, err = (&DBConfig{Type: PostgreSQL, Host: "db", Database: "app", Params: "options='-c x"}).BuildDSN()
+	assert.ErrorContains(t, err, "unterminated quoted value")
+}
@@ -3,11 +3,19 @@
 package db
 
 import (
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
+	"strings"
 )
 
-// newSQLiteDialector creates a SQLite dialector from the given DSN
-func newSQLiteDialector(dsn string) gorm.Dialector {
-	return sqlite.Open(dsn)
+// errSQLiteDriver is nil because SQLite support is compiled in
+var errSQLiteDriver error
+
+// isSQLiteRetryable reports whether err is SQLITE_BUSY or SQLITE_LOCKED. The
+// message is matched because the cgo and pure-Go drivers have different error
+// types, and the cgo driver's type is only defined with cgo.
+func isSQLiteRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked")
 }
@@ -0,0 +1,18 @@
+//go:build !no_sqlite && (all_db || sqlite || !no_default_db) && cgo && !sqlite_purego
+
+package db
+
+import (
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newSQLiteDialector creates a SQLite dialector from the given DSN
+func newSQLiteDialector(dsn string) (gorm.Dialector, error) {
+	return sqlite.Open(dsn), nil
+}
+
+// newSQLiteConnDialector creates a SQLite dialector that reuses an open connection pool
+func newSQLiteConnDialector(conn gorm.ConnPool) (gorm.Dialector, error) {
+	return sqlite.New(sqlite.Config{Conn: conn}), nil
+}
@@ -0,0 +1,60 @@
+//go:build !no_sqlite && (all_db || sqlite || !no_default_db) && (!cgo || sqlite_purego)
+
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// sqlitePragmaParams maps the DSN params of the cgo driver to the pragmas
+// they set, so that DSNs work with either driver
+var sqlitePragmaParams = map[string]string{
+	"_busy_timeout": "busy_timeout",
+	"_timeout":      "busy_timeout",
+	"_foreign_keys": "foreign_keys",
+	"_fk":           "foreign_keys",
+	"_journal_mode": "journal_mode",
+	"_journal":      "journal_mode",
+	"_synchronous":  "synchronous",
+	"_sync":         "synchronous",
+}
+
+// newSQLiteDialector creates a pure-Go SQLite dialector from the given DSN
+func newSQLiteDialector(dsn string) (gorm.Dialector, error) {
+	dsn, err := pureGoSQLiteDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sqlite.Open(dsn), nil
+}
+
+// newSQLiteConnDialector creates a pure-Go SQLite dialector that reuses an open connection pool
+func newSQLiteConnDialector(conn gorm.ConnPool) (gorm.Dialector, error) {
+	return &sqlite.Dialector{Conn: conn}, nil
+}
+
+// pureGoSQLiteDSN rewrites the params of the cgo driver listed in
+// sqlitePragmaParams to _pragma params, e.g. _busy_timeout=0 to
+// _pragma=busy_timeout(0). Other params are kept.
+func pureGoSQLiteDSN(dsn string) (string, error) {
+	path, params, ok := strings.Cut(dsn, "?")
+	if !ok {
+		return dsn, nil
+	}
+	query, err := url.ParseQuery(params)
+	if err != nil {
+		return "", fmt.Errorf("invalid SQLite DSN params: %w", err)
+	}
+	for key, pragma := range sqlitePragmaParams {
+		if query.Has(key) {
+			query.Add("_pragma", fmt.Sprintf("%s(%s)", pragma, query.Get(key)))
+			query.Del(key)
+		}
+	}
+	return path + "?" + query.Encode(), nil
+}
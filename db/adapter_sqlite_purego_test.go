@@ -0,0 +1,46 @@
+//go:build !no_sqlite && (all_db || sqlite || !no_default_db) && (!cgo || sqlite_purego)
+
+package db
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPureGoSQLiteDSN(t *testing.T) {
+	dsn, err := pureGoSQLiteDSN("app.db")
+	require.NoError(t, err)
+	assert.Equal(t, "app.db", dsn)
+
+	dsn, err = pureGoSQLiteDSN("/var/lib/app.db?_busy_timeout=100&_fk=1&_txlock=immediate")
+	require.NoError(t, err)
+	path, rawParams, _ := strings.Cut(dsn, "?")
+	assert.Equal(t, "/var/lib/app.db", path)
+	params, err := url.ParseQuery(rawParams)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"busy_timeout(100)", "foreign_keys(1)"}, params["_pragma"])
+	assert.Equal(t, "immediate", params.Get("_txlock"))
+	assert.False(t, params.Has("_busy_timeout"))
+
+	_, err = pureGoSQLiteDSN("app.db?%zz")
+	assert.ErrorContains(t, err, "invalid SQLite DSN params")
+}
+
+func TestSQLiteDialector_PureGo(t *testing.T) {
+	dialector, err := newSQLiteDialector(":memory:?_foreign_keys=true")
+	require.NoError(t, err)
+	assert.IsType(t, &sqlite.Dialector{}, dialector)
+
+	manager, err := NewGORMManager(dialector)
+	require.NoError(t, err)
+	defer manager.Close()
+
+	var enabled bool
+	require.NoError(t, manager.DB().Raw("PRAGMA foreign_keys").Scan(&enabled).Error)
+	assert.True(t, enabled)
+}
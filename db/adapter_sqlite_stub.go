@@ -3,12 +3,23 @@
 package db
 
 import (
-	"fmt"
-
 	"gorm.io/gorm"
 )
 
+// errSQLiteDriver reports that SQLite support is disabled by build tags
+var errSQLiteDriver = driverNotCompiled("SQLite", "sqlite")
+
 // newSQLiteDialector is a stub that returns an error when SQLite support is disabled
-func newSQLiteDialector(dsn string) gorm.Dialector {
-	panic(fmt.Errorf("SQLite support not compiled in. Build with -tags sqlite to enable"))
+func newSQLiteDialector(dsn string) (gorm.Dialector, error) {
+	return nil, errSQLiteDriver
+}
+
+// newSQLiteConnDialector is a stub that returns an error when SQLite support is disabled
+func newSQLiteConnDialector(conn gorm.ConnPool) (gorm.Dialector, error) {
+	return nil, errSQLiteDriver
+}
+
+// isSQLiteRetryable is a stub that reports no error as retryable when SQLite support is disabled
+func isSQLiteRetryable(err error) bool {
+	return false
 }
@@ -0,0 +1,34 @@
+//go:build !no_sqlserver && (all_db || sqlserver || !no_default_db)
+
+package db
+
+import (
+	"errors"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"gorm.io/driver/sqlserver"
+	"gorm.io/gorm"
+)
+
+// errSQLServerDriver is nil because SQL Server support is compiled in
+var errSQLServerDriver error
+
+// newSQLServerDialector creates a SQL Server dialector from the given DSN
+func newSQLServerDialector(dsn string) (gorm.Dialector, error) {
+	return sqlserver.Open(dsn), nil
+}
+
+// newSQLServerConnDialector creates a SQL Server dialector that reuses an open connection pool
+func newSQLServerConnDialector(conn gorm.ConnPool) (gorm.Dialector, error) {
+	return sqlserver.New(sqlserver.Config{Conn: conn}), nil
+}
+
+// isSQLServerRetryable reports whether err is a deadlock victim (1205) or a
+// snapshot isolation update conflict (3960)
+func isSQLServerRetryable(err error) bool {
+	var mssqlErr mssql.Error
+	if !errors.As(err, &mssqlErr) {
+		return false
+	}
+	return mssqlErr.Number == 1205 || mssqlErr.Number == 3960
+}
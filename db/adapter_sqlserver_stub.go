@@ -0,0 +1,25 @@
+//go:build no_sqlserver || (!all_db && !sqlserver && no_default_db)
+
+package db
+
+import (
+	"gorm.io/gorm"
+)
+
+// errSQLServerDriver reports that SQL Server support is disabled by build tags
+var errSQLServerDriver = driverNotCompiled("SQL Server", "sqlserver")
+
+// newSQLServerDialector is a stub that returns an error when SQL Server support is disabled
+func newSQLServerDialector(dsn string) (gorm.Dialector, error) {
+	return nil, errSQLServerDriver
+}
+
+// newSQLServerConnDialector is a stub that returns an error when SQL Server support is disabled
+func newSQLServerConnDialector(conn gorm.ConnPool) (gorm.Dialector, error) {
+	return nil, errSQLServerDriver
+}
+
+// isSQLServerRetryable is a stub that reports no error as retryable when SQL Server support is disabled
+func isSQLServerRetryable(err error) bool {
+	return false
+}
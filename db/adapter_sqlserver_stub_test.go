@@ -0,0 +1,22 @@
+//go:build no_sqlserver || (!all_db && !sqlserver && no_default_db)
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLServerStub(t *testing.T) {
+	assert.NotContains(t, SupportedTypes(), SQLServer)
+
+	_, err := NewGORMManagerFromConfig(ManagerConfig{Primary: DBConfig{Type: SQLServer, Host: "localhost", Database: "app"}})
+	assert.ErrorIs(t, err, ErrDriverNotCompiled)
+
+	_, err = createDialector(DBConfig{Type: SQLServer, DSN: "sqlserver://sa@localhost:1433?database=app"})
+	assert.ErrorIs(t, err, ErrDriverNotCompiled)
+
+	err = ManagerConfig{Primary: DBConfig{Type: SQLServer, Host: "localhost", Database: "app"}}.Validate()
+	assert.ErrorContains(t, err, "primary.type: db: driver not compiled in: SQL Server support not compiled in")
+}
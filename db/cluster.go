@@ -0,0 +1,80 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// ClusterConfig is a named group of sources and replicas serving a subset of
+// tables, such as audit tables kept in a separate database. Statements on the
+// listed tables and models are routed to the cluster instead of the default
+// sources and replicas.
+type ClusterConfig struct {
+	// Sources is a list of write database configurations.
+	// If empty, writes go to Primary.
+	Sources []DBConfig
+
+	// Replicas is a list of read-only database configurations.
+	// If empty, reads go to the cluster's Sources.
+	Replicas []DBConfig
+
+	// Tables are the names of the tables routed to the cluster
+	Tables []string
+
+	// Models are model values (e.g. &AuditLog{}) whose tables are routed to the cluster
+	Models []any
+
+	// Policy selects how connections are chosen among Sources and among Replicas
+	// (default ManagerConfig.Policy)
+	Policy LoadBalancePolicy
+}
+
+// clusterKey returns the dbresolver key selecting the named cluster. dbresolver
+// also matches string keys against table names, so the name is namespaced to
+// keep a cluster named like a table from capturing that table's statements.
+func clusterKey(name string) string {
+	return "cluster:" + name
+}
+
+// datas returns the dbresolver keys for the cluster: its clusterKey, so that
+// dbresolver.Use selects it, followed by its tables and models
+func (c ClusterConfig) datas(name string) []any {
+	datas := make([]any, 0, 1+len(c.Tables)+len(c.Models))
+	datas = append(datas, clusterKey(name))
+	for _, table := range c.Tables {
+		datas = append(datas, table)
+	}
+	return append(datas, c.Models...)
+}
+
+// clusterNames returns the configured cluster names in a stable order
+func (m *GORMManager) clusterNames() []string {
+	names := make([]string, 0, len(m.config.Clusters))
+	for name := range m.config.Clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Cluster returns a *gorm.DB whose statements are routed to the named cluster,
+// regardless of the table they use. Writes go to the cluster's sources and
+// reads to its replicas.
+//
+// Parameters:
+// - name: the cluster name from ManagerConfig.Clusters.
+//
+// Returns:
+// - *gorm.DB: the database routed to the cluster. If the cluster does not
+// exist, its Error field is set and every operation fails.
+func (m *GORMManager) Cluster(name string) *gorm.DB {
+	if _, ok := m.config.Clusters[name]; !ok {
+		db := m.db.Session(&gorm.Session{})
+		_ = db.AddError(fmt.Errorf("unknown database cluster: %s", name))
+		return db
+	}
+	return m.db.Clauses(dbresolver.Use(clusterKey(name)))
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type auditEvent struct {
+	ID     uint
+	Action string
+}
+
+func newClusterManager(t *testing.T) *GORMManager {
+	t.Helper()
+	dir := t.TempDir()
+
+	manager, err := NewGORMManagerFromConfig(ManagerConfig{
+		Primary:  sqliteConfig(t, dir, "primary"),
+		Replicas: []DBConfig{sqliteConfig(t, dir, "replica0")},
+		Clusters: map[string]ClusterConfig{
+			"audit": {
+				Sources:  []DBConfig{sqliteConfig(t, dir, "audit_source0")},
+				Replicas: []DBConfig{sqliteConfig(t, dir, "audit_replica0")},
+				Tables:   []string{"audit_logs"},
+				Models:   []any{&auditEvent{}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+
+	seedNodes(t, manager)
+	for _, n := range manager.nodes {
+		_, err := n.sqlDB.Exec("CREATE TABLE audit_logs (name TEXT)")
+		require.NoError(t, err)
+		_, err = n.sqlDB.Exec("INSERT INTO audit_logs (name) VALUES (?)", n.name())
+		require.NoError(t, err)
+		_, err = n.sqlDB.Exec("CREATE TABLE audit_events (id INTEGER PRIMARY KEY, action TEXT)")
+		require.NoError(t, err)
+	}
+	return manager
+}
+
+func TestCluster_TableRouting(t *testing.T) {
+	manager := newClusterManager(t)
+
+	names := make([]string, 0, len(manager.nodes))
+	for _, n := range manager.nodes {
+		names = append(names, n.name())
+	}
+	assert.Equal(t, []string{"primary", "replica-0", "audit/source-0", "audit/replica-0"}, names)
+
+	assert.Equal(t, "replica-0", readNode(t, manager))
+
+	var name string
+	require.NoError(t, manager.DB().Table("audit_logs").Select("name").Limit(1).Scan(&name).Error)
+	assert.Equal(t, "audit/replica-0", name)
+}
+
+func TestCluster_ModelRouting(t *testing.T) {
+	manager := newClusterManager(t)
+
+	require.NoError(t, manager.DB().Create(&auditEvent{Action: "login"}).Error)
+
+	counts := make(map[string]int)
+	for _, n := range manager.nodes {
+		var count int
+		require.NoError(t, n.sqlDB.QueryRow("SELECT COUNT(*) FROM audit_events").Scan(&count))
+		counts[n.name()] = count
+	}
+	assert.Equal(t, map[string]int{"primary": 0, "replica-0": 0, "audit/source-0": 1, "audit/replica-0": 0}, counts)
+}
+
+func TestCluster_Accessor(t *testing.T) {
+	manager := newClusterManager(t)
+
+	var name string
+	require.NoError(t, manager.Cluster("audit").Table("nodes").Select("name").Limit(1).Scan(&name).Error)
+	assert.Equal(t, "audit/replica-0", name)
+
+	err := manager.Cluster("billing").Table("nodes").Select("name").Limit(1).Scan(&name).Error
+	assert.ErrorContains(t, err, "unknown database cluster: billing")
+}
+
+func TestCluster_NamedLikeTable(t *testing.T) {
+	dir := t.TempDir()
+	manager, err := NewGORMManagerFromConfig(ManagerConfig{
+		Primary:  sqliteConfig(t, dir, "primary"),
+		Replicas: []DBConfig{sqliteConfig(t, dir, "replica0")},
+		Clusters: map[string]ClusterConfig{
+			"nodes": {Sources: []DBConfig{sqliteConfig(t, dir, "nodes_source0")}, Tables: []string{"audit_logs"}},
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+	seedNodes(t, manager)
+
+	assert.Equal(t, "replica-0", readNode(t, manager), "the nodes table is not routed to the nodes cluster")
+	var name string
+	require.NoError(t, manager.Cluster("nodes").Table("nodes").Select("name").Limit(1).Scan(&name).Error)
+	assert.Equal(t, "nodes/source-0", name)
+}
+
+func TestCluster_EvictedReplica(t *testing.T) {
+	manager := newClusterManager(t)
+	manager.nodes[3].evicted.Store(true)
+
+	var name string
+	require.NoError(t, manager.DB().Table("audit_logs").Select("name").Limit(1).Scan(&name).Error)
+	assert.Equal(t, "audit/source-0", name)
+	assert.Equal(t, "replica-0", readNode(t, manager))
+}
+
+func TestCluster_Empty(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewGORMManagerFromConfig(ManagerConfig{
+		Primary:  sqliteConfig(t, dir, "primary"),
+		Clusters: map[string]ClusterConfig{"audit": {Tables: []string{"audit_logs"}}},
+	})
+	assert.ErrorContains(t, err, "cluster audit has no sources or replicas")
+}
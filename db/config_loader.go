@@ -0,0 +1,516 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ducminhgd/gao/defaults"
+	"gopkg.in/yaml.v3"
+)
+
+// duration is a time.Duration read from strings such as "10m"
+type duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", text)
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// managerSpec is the file and environment form of ManagerConfig
+type managerSpec struct {
+	Primary             dbSpec                 `json:"primary" yaml:"primary"`
+	Sources             []dbSpec               `json:"sources" yaml:"sources"`
+	Replicas            []dbSpec               `json:"replicas" yaml:"replicas"`
+	Clusters            map[string]clusterSpec `json:"clusters" yaml:"clusters"`
+	Policy              string                 `json:"policy" yaml:"policy"`
+	StickyPrimaryWindow duration               `json:"sticky_primary_window" yaml:"sticky_primary_window"`
+	HealthCheck         healthCheckSpec        `json:"health_check" yaml:"health_check"`
+	ReplicaLag          replicaLagSpec         `json:"replica_lag" yaml:"replica_lag"`
+	StatsInterval       duration               `json:"stats_interval" yaml:"stats_interval"`
+}
+
+// dbSpec is the file and environment form of DBConfig
+type dbSpec struct {
+	URL      string   `json:"url" yaml:"url"`
+	Type     string   `json:"type" yaml:"type"`
+	DSN      string   `json:"dsn" yaml:"dsn"`
+	Host     string   `json:"host" yaml:"host"`
+	Port     int      `json:"port" yaml:"port"`
+	Username string   `json:"username" yaml:"username"`
+	Password string   `json:"password" yaml:"password"`
+	Database string   `json:"database" yaml:"database"`
+	Params   string   `json:"params" yaml:"params"`
+	TLS      tlsSpec  `json:"tls" yaml:"tls"`
+	Pool     poolSpec `json:"pool" yaml:"pool"`
+	Weight   int      `json:"weight" yaml:"weight"`
+}
+
+// tlsSpec is the file and environment form of TLSConfig
+type tlsSpec struct {
+	Mode     string `json:"mode" yaml:"mode"`
+	CAFile   string `json:"ca_file" yaml:"ca_file"`
+	CertFile string `json:"cert_file" yaml:"cert_file"`
+	KeyFile  string `json:"key_file" yaml:"key_file"`
+}
+
+// poolSpec is the file and environment form of PoolConfig
+type poolSpec struct {
+	MaxOpenConns    int      `json:"max_open_conns" yaml:"max_open_conns"`
+	MaxIdleConns    int      `json:"max_idle_conns" yaml:"max_idle_conns"`
+	ConnMaxLifetime duration `json:"conn_max_lifetime" yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime duration `json:"conn_max_idle_time" yaml:"conn_max_idle_time"`
+}
+
+// clusterSpec is the file form of ClusterConfig
+type clusterSpec struct {
+	Sources  []dbSpec `json:"sources" yaml:"sources"`
+	Replicas []dbSpec `json:"replicas" yaml:"replicas"`
+	Tables   []string `json:"tables" yaml:"tables"`
+	Policy   string   `json:"policy" yaml:"policy"`
+}
+
+// healthCheckSpec is the file and environment form of HealthCheckConfig
+type healthCheckSpec struct {
+	Timeout          duration `json:"timeout" yaml:"timeout"`
+	Interval         duration `json:"interval" yaml:"interval"`
+	FailureThreshold int      `json:"failure_threshold" yaml:"failure_threshold"`
+	SuccessThreshold int      `json:"success_threshold" yaml:"success_threshold"`
+}
+
+// replicaLagSpec is the file and environment form of ReplicaLagConfig
+type replicaLagSpec struct {
+	MaxLag   duration `json:"max_lag" yaml:"max_lag"`
+	Interval duration `json:"interval" yaml:"interval"`
+	Query    string   `json:"query" yaml:"query"`
+}
+
+// ManagerConfigFromEnv loads a ManagerConfig from environment variables named
+// PREFIX_PRIMARY_FIELD, PREFIX_SOURCE_<i>_FIELD and PREFIX_REPLICA_<i>_FIELD,
+// where <i> counts from 0, e.g. DB_PRIMARY_HOST or DB_REPLICA_0_URL. FIELD is
+// one of URL, TYPE, DSN, HOST, PORT, USERNAME, PASSWORD, DATABASE, PARAMS,
+// WEIGHT, TLS_MODE, TLS_CA_FILE, TLS_CERT_FILE, TLS_KEY_FILE,
+// POOL_MAX_OPEN_CONNS, POOL_MAX_IDLE_CONNS, POOL_CONN_MAX_LIFETIME and
+// POOL_CONN_MAX_IDLE_TIME. URL is parsed with ParseURL and the other fields
+// override it. Durations use time.ParseDuration syntax ("10m").
+//
+// The manager settings are PREFIX_POLICY, PREFIX_STICKY_PRIMARY_WINDOW,
+// PREFIX_HEALTH_CHECK_TIMEOUT, PREFIX_HEALTH_CHECK_INTERVAL,
+// PREFIX_HEALTH_CHECK_FAILURE_THRESHOLD, PREFIX_HEALTH_CHECK_SUCCESS_THRESHOLD,
+// PREFIX_REPLICA_LAG_MAX_LAG, PREFIX_REPLICA_LAG_INTERVAL,
+// PREFIX_REPLICA_LAG_QUERY and PREFIX_STATS_INTERVAL. Clusters can only be loaded from files.
+//
+// Unset pool settings take the `default` tags of PoolConfig, and the config is
+// checked with ManagerConfig.Validate.
+//
+// Parameters:
+// - prefix: the variable name prefix (default "DB").
+//
+// Returns:
+// - ManagerConfig: the loaded config; GormConfig and Logger are left unset.
+// - error: an error listing every invalid variable or setting.
+func ManagerConfigFromEnv(prefix string) (ManagerConfig, error) {
+	if prefix == "" {
+		prefix = "DB"
+	}
+	env := &envReader{prefix: strings.ToUpper(prefix) + "_"}
+
+	var spec managerSpec
+	env.db("PRIMARY_", &spec.Primary)
+	sources, replicas := env.count("SOURCE_"), env.count("REPLICA_")
+	for i := 0; i < sources; i++ {
+		var source dbSpec
+		env.db(fmt.Sprintf("SOURCE_%d_", i), &source)
+		spec.Sources = append(spec.Sources, source)
+	}
+	for i := 0; i < replicas; i++ {
+		var replica dbSpec
+		env.db(fmt.Sprintf("REPLICA_%d_", i), &replica)
+		spec.Replicas = append(spec.Replicas, replica)
+	}
+
+	env.string("POLICY", &spec.Policy)
+	env.duration("STICKY_PRIMARY_WINDOW", &spec.StickyPrimaryWindow)
+	env.duration("HEALTH_CHECK_TIMEOUT", &spec.HealthCheck.Timeout)
+	env.duration("HEALTH_CHECK_INTERVAL", &spec.HealthCheck.Interval)
+	env.int("HEALTH_CHECK_FAILURE_THRESHOLD", &spec.HealthCheck.FailureThreshold)
+	env.int("HEALTH_CHECK_SUCCESS_THRESHOLD", &spec.HealthCheck.SuccessThreshold)
+	env.duration("REPLICA_LAG_MAX_LAG", &spec.ReplicaLag.MaxLag)
+	env.duration("REPLICA_LAG_INTERVAL", &spec.ReplicaLag.Interval)
+	env.string("REPLICA_LAG_QUERY", &spec.ReplicaLag.Query)
+	env.duration("STATS_INTERVAL", &spec.StatsInterval)
+
+	if err := errors.Join(env.errs...); err != nil {
+		return ManagerConfig{}, err
+	}
+	return spec.config()
+}
+
+// envReader reads prefixed environment variables, collecting parse errors
+type envReader struct {
+	prefix string
+	errs   []error
+}
+
+// lookup returns the value of the prefixed variable name
+func (r *envReader) lookup(name string) (string, bool) {
+	return os.LookupEnv(r.prefix + name)
+}
+
+// string sets target to the variable name if it is set
+func (r *envReader) string(name string, target *string) {
+	if value, ok := r.lookup(name); ok {
+		*target = value
+	}
+}
+
+// int sets target to the variable name if it is set
+func (r *envReader) int(name string, target *int) {
+	value, ok := r.lookup(name)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s%s: invalid integer %q", r.prefix, name, value))
+		return
+	}
+	*target = n
+}
+
+// duration sets target to the variable name if it is set
+func (r *envReader) duration(name string, target *duration) {
+	value, ok := r.lookup(name)
+	if !ok {
+		return
+	}
+	if err := target.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s%s: %w", r.prefix, name, err))
+	}
+}
+
+// db reads the database variables starting with group
+func (r *envReader) db(group string, spec *dbSpec) {
+	r.string(group+"URL", &spec.URL)
+	r.string(group+"TYPE", &spec.Type)
+	r.string(group+"DSN", &spec.DSN)
+	r.string(group+"HOST", &spec.Host)
+	r.int(group+"PORT", &spec.Port)
+	r.string(group+"USERNAME", &spec.Username)
+	r.string(group+"PASSWORD", &spec.Password)
+	r.string(group+"DATABASE", &spec.Database)
+	r.string(group+"PARAMS", &spec.Params)
+	r.int(group+"WEIGHT", &spec.Weight)
+	r.string(group+"TLS_MODE", &spec.TLS.Mode)
+	r.string(group+"TLS_CA_FILE", &spec.TLS.CAFile)
+	r.string(group+"TLS_CERT_FILE", &spec.TLS.CertFile)
+	r.string(group+"TLS_KEY_FILE", &spec.TLS.KeyFile)
+	r.int(group+"POOL_MAX_OPEN_CONNS", &spec.Pool.MaxOpenConns)
+	r.int(group+"POOL_MAX_IDLE_CONNS", &spec.Pool.MaxIdleConns)
+	r.duration(group+"POOL_CONN_MAX_LIFETIME", &spec.Pool.ConnMaxLifetime)
+	r.duration(group+"POOL_CONN_MAX_IDLE_TIME", &spec.Pool.ConnMaxIdleTime)
+}
+
+// count returns the number of indexed groups, such as REPLICA_0_ and
+// REPLICA_1_, set in the environment. Indexes must be contiguous from 0.
+func (r *envReader) count(group string) int {
+	prefix := r.prefix + group
+	seen := make(map[int]bool)
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		digits, _, ok := strings.Cut(rest, "_")
+		if i, err := strconv.Atoi(digits); ok && err == nil && i >= 0 {
+			seen[i] = true
+		}
+	}
+
+	indexes := make([]int, 0, len(seen))
+	for i := range seen {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	for n, i := range indexes {
+		if n != i {
+			r.errs = append(r.errs, fmt.Errorf("%s%d_* is set but %s%d_* is not", prefix, i, prefix, n))
+			return 0
+		}
+	}
+	return len(indexes)
+}
+
+// ManagerConfigFromFile loads a ManagerConfig from a YAML (.yaml, .yml) or JSON
+// (.json) file. Keys are the snake_case names of the ManagerConfig and DBConfig
+// fields, with the pool settings under pool and the TLS settings under tls:
+//
+//	primary:
+//	  url: postgres://app:secret@db:5432/orders?sslmode=require
+//	  pool:
+//	    max_open_conns: 20
+//	replicas:
+//	  - host: replica-0
+//	    type: postgres
+//	    database: orders
+//	policy: round_robin
+//
+// Unknown keys are rejected. Unset pool settings take the `default` tags of
+// PoolConfig, and the config is checked with ManagerConfig.Validate.
+//
+// Parameters:
+// - path: the file path.
+//
+// Returns:
+// - ManagerConfig: the loaded config; GormConfig and Logger are left unset.
+// - error: an error if the file cannot be read or decoded, or the config is invalid.
+func ManagerConfigFromFile(path string) (ManagerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ManagerConfig{}, fmt.Errorf("failed to read database config: %w", err)
+	}
+
+	var spec managerSpec
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&spec)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&spec)
+	default:
+		return ManagerConfig{}, fmt.Errorf("unsupported database config file extension %q (supported: .yaml, .yml, .json)", ext)
+	}
+	if err != nil {
+		return ManagerConfig{}, fmt.Errorf("failed to decode database config %s: %w", path, err)
+	}
+	return spec.config()
+}
+
+// config converts the spec to a validated ManagerConfig
+func (s managerSpec) config() (ManagerConfig, error) {
+	var errs []error
+	convert := func(path string, specs []dbSpec) []DBConfig {
+		configs := make([]DBConfig, 0, len(specs))
+		for i, spec := range specs {
+			config, err := spec.config()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s[%d]: %w", path, i, err))
+			}
+			configs = append(configs, config)
+		}
+		return configs
+	}
+
+	primary, err := s.Primary.config()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("primary: %w", err))
+	}
+	config := ManagerConfig{
+		Primary:             primary,
+		Policy:              LoadBalancePolicy(s.Policy),
+		StickyPrimaryWindow: time.Duration(s.StickyPrimaryWindow),
+		HealthCheck: HealthCheckConfig{
+			Timeout:          time.Duration(s.HealthCheck.Timeout),
+			Interval:         time.Duration(s.HealthCheck.Interval),
+			FailureThreshold: s.HealthCheck.FailureThreshold,
+			SuccessThreshold: s.HealthCheck.SuccessThreshold,
+		},
+		ReplicaLag: ReplicaLagConfig{
+			MaxLag:   time.Duration(s.ReplicaLag.MaxLag),
+			Interval: time.Duration(s.ReplicaLag.Interval),
+			Query:    s.ReplicaLag.Query,
+		},
+		StatsInterval: time.Duration(s.StatsInterval),
+	}
+	if len(s.Sources) > 0 {
+		config.Sources = convert("sources", s.Sources)
+	}
+	if len(s.Replicas) > 0 {
+		config.Replicas = convert("replicas", s.Replicas)
+	}
+	if len(s.Clusters) > 0 {
+		config.Clusters = make(map[string]ClusterConfig, len(s.Clusters))
+		for name, cluster := range s.Clusters {
+			config.Clusters[name] = ClusterConfig{
+				Sources:  convert(fmt.Sprintf("clusters.%s.sources", name), cluster.Sources),
+				Replicas: convert(fmt.Sprintf("clusters.%s.replicas", name), cluster.Replicas),
+				Tables:   cluster.Tables,
+				Policy:   LoadBalancePolicy(cluster.Policy),
+			}
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return ManagerConfig{}, err
+	}
+	if err := config.Validate(); err != nil {
+		return ManagerConfig{}, err
+	}
+	return config, nil
+}
+
+// config converts the spec to a DBConfig, starting from URL if it is set
+func (s dbSpec) config() (DBConfig, error) {
+	var config DBConfig
+	if s.URL != "" {
+		parsed, err := ParseURL(s.URL)
+		if err != nil {
+			return DBConfig{}, fmt.Errorf("url: %w", err)
+		}
+		config = parsed
+	}
+
+	setString := func(target *string, value string) {
+		if value != "" {
+			*target = value
+		}
+	}
+	if s.Type != "" {
+		config.Type = DatabaseType(strings.ToLower(s.Type))
+		if config.Type == "postgresql" {
+			config.Type = PostgreSQL
+		}
+	}
+	setString(&config.DSN, s.DSN)
+	setString(&config.Host, s.Host)
+	setString(&config.Username, s.Username)
+	setString(&config.Password, s.Password)
+	setString(&config.Database, s.Database)
+	setString(&config.Params, s.Params)
+	if s.Port != 0 {
+		config.Port = s.Port
+	}
+	if s.TLS.Mode != "" {
+		config.TLS.Mode = TLSMode(s.TLS.Mode)
+	}
+	setString(&config.TLS.CAFile, s.TLS.CAFile)
+	setString(&config.TLS.CertFile, s.TLS.CertFile)
+	setString(&config.TLS.KeyFile, s.TLS.KeyFile)
+	config.Weight = s.Weight
+
+	config.PoolConfig = PoolConfig{
+		ConnMaxIdleTime: time.Duration(s.Pool.ConnMaxIdleTime),
+		ConnMaxLifetime: time.Duration(s.Pool.ConnMaxLifetime),
+		MaxIdleConns:    s.Pool.MaxIdleConns,
+		MaxOpenConns:    s.Pool.MaxOpenConns,
+	}
+	if err := defaults.Apply(&config.PoolConfig); err != nil {
+		return DBConfig{}, err
+	}
+	return config, nil
+}
+
+// Validate checks the config for missing or invalid settings, such as a
+// source without a host or an unknown load balance policy, so that
+// configuration mistakes are reported together and before connecting.
+//
+// Returns:
+// - error: nil if the config is valid, or an error listing every problem,
+// one per line, prefixed with the setting's path (e.g. "replicas[1].host").
+func (c ManagerConfig) Validate() error {
+	var errs []error
+	errs = append(errs, c.Primary.validate("primary")...)
+	for i, source := range c.Sources {
+		errs = append(errs, source.validate(fmt.Sprintf("sources[%d]", i))...)
+	}
+	for i, replica := range c.Replicas {
+		errs = append(errs, replica.validate(fmt.Sprintf("replicas[%d]", i))...)
+	}
+	if _, err := newPolicy(c.Policy, nil); err != nil {
+		errs = append(errs, fmt.Errorf("policy: %w", err))
+	}
+
+	names := make([]string, 0, len(c.Clusters))
+	for name := range c.Clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cluster := c.Clusters[name]
+		path := "clusters." + name
+		if len(cluster.Sources) == 0 && len(cluster.Replicas) == 0 {
+			errs = append(errs, fmt.Errorf("%s: at least one source or replica is required", path))
+		}
+		for i, source := range cluster.Sources {
+			errs = append(errs, source.validate(fmt.Sprintf("%s.sources[%d]", path, i))...)
+		}
+		for i, replica := range cluster.Replicas {
+			errs = append(errs, replica.validate(fmt.Sprintf("%s.replicas[%d]", path, i))...)
+		}
+		if _, err := newPolicy(cluster.Policy, nil); err != nil {
+			errs = append(errs, fmt.Errorf("%s.policy: %w", path, err))
+		}
+	}
+
+	if c.StickyPrimaryWindow < 0 {
+		errs = append(errs, errors.New("sticky_primary_window: must not be negative"))
+	}
+	if c.HealthCheck.FailureThreshold < 0 || c.HealthCheck.SuccessThreshold < 0 {
+		errs = append(errs, errors.New("health_check: thresholds must not be negative"))
+	}
+	if c.StatsInterval < 0 {
+		errs = append(errs, errors.New("stats_interval: must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// validate returns the problems of the config, prefixed with path
+func (c DBConfig) validate(path string) []error {
+	var errs []error
+	if c.Type == "" {
+		errs = append(errs, fmt.Errorf("%s.type: required (supported: %s)", path, joinTypes(SupportedTypes())))
+	} else if err := driverError(c.Type); err != nil {
+		errs = append(errs, fmt.Errorf("%s.type: %w", path, err))
+	}
+
+	switch c.Type {
+	case MySQL, PostgreSQL, SQLServer, ClickHouse:
+		if c.DSN == "" && c.Host == "" {
+			errs = append(errs, fmt.Errorf("%s.host: required when dsn and url are empty", path))
+		}
+		if c.DSN == "" && c.Database == "" {
+			errs = append(errs, fmt.Errorf("%s.database: required when dsn and url are empty", path))
+		}
+	case SQLite:
+		if c.DSN == "" && c.Database == "" {
+			errs = append(errs, fmt.Errorf("%s.database: the SQLite file path is required when dsn and url are empty", path))
+		}
+	}
+
+	if c.Port < 0 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("%s.port: %d is out of range", path, c.Port))
+	}
+	if c.Weight < 0 {
+		errs = append(errs, fmt.Errorf("%s.weight: must not be negative", path))
+	}
+	if err := c.TLS.validate(); err != nil {
+		errs = append(errs, fmt.Errorf("%s.tls: %w", path, err))
+	}
+	// Negative pool settings are valid and mean no limit, see NoPoolLimit
+	return errs
+}
+
+// joinTypes formats database types as a comma-separated list
+func joinTypes(types []DatabaseType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,9 @@
+// Package db opens GORM connections from DBConfig for MySQL, PostgreSQL,
+// SQLite, SQL Server and ClickHouse, with read replicas, clusters, health
+// checks, pool statistics and per-tenant databases.
+//
+// TenantManager closes tenant connections on MaxTenants and IdleTimeout
+// eviction. Request handlers should use TenantManager.AcquireForContext (or
+// Acquire) and defer the returned release; the unleased DB, DBForContext and
+// Manager accessors can return a handle that is closed while still in use.
+package db
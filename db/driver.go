@@ -0,0 +1,54 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDriverNotCompiled is returned when the driver of a database type was
+// excluded by build tags (see BUILD_TAGS.md)
+var ErrDriverNotCompiled = errors.New("db: driver not compiled in")
+
+// databaseTypes lists the database types known to the package
+var databaseTypes = []DatabaseType{MySQL, PostgreSQL, SQLite, SQLServer, ClickHouse}
+
+// driverNotCompiled returns an error wrapping ErrDriverNotCompiled for the
+// database name and the build tag that enables it
+func driverNotCompiled(name, tag string) error {
+	return fmt.Errorf("%w: %s support not compiled in. Build with -tags %s to enable", ErrDriverNotCompiled, name, tag)
+}
+
+// driverError returns nil if the driver of dbType is compiled in, an error
+// wrapping ErrDriverNotCompiled if it was excluded by build tags, or an error
+// if dbType is unknown
+func driverError(dbType DatabaseType) error {
+	switch dbType {
+	case MySQL:
+		return errMySQLDriver
+	case PostgreSQL:
+		return errPostgreSQLDriver
+	case SQLite:
+		return errSQLiteDriver
+	case SQLServer:
+		return errSQLServerDriver
+	case ClickHouse:
+		return errClickHouseDriver
+	default:
+		return fmt.Errorf("unsupported database type: %s", dbType)
+	}
+}
+
+// SupportedTypes returns the database types whose drivers are compiled in,
+// which depends on the build tags.
+//
+// Returns:
+// - []DatabaseType: the supported database types.
+func SupportedTypes() []DatabaseType {
+	types := make([]DatabaseType, 0, len(databaseTypes))
+	for _, dbType := range databaseTypes {
+		if driverError(dbType) == nil {
+			types = append(types, dbType)
+		}
+	}
+	return types
+}
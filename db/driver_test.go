@@ -0,0 +1,52 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportedTypes(t *testing.T) {
+	supported := SupportedTypes()
+	for _, dbType := range databaseTypes {
+		_, err := NewGORMManagerFromConfig(ManagerConfig{Primary: DBConfig{Type: dbType}})
+		require.Error(t, err)
+		if containsType(supported, dbType) {
+			assert.NotErrorIs(t, err, ErrDriverNotCompiled, dbType)
+			assert.ErrorContains(t, err, "DSN is empty", dbType)
+		} else {
+			assert.ErrorIs(t, err, ErrDriverNotCompiled, dbType)
+		}
+	}
+
+	assert.NotContains(t, supported, DatabaseType("oracle"))
+	assert.ErrorContains(t, driverError("oracle"), "unsupported database type")
+}
+
+func TestDriverNotCompiled(t *testing.T) {
+	err := driverNotCompiled("MySQL", "mysql")
+	assert.True(t, errors.Is(err, ErrDriverNotCompiled))
+	assert.EqualError(t, err, "db: driver not compiled in: MySQL support not compiled in. Build with -tags mysql to enable")
+}
+
+// containsType reports whether types contains dbType
+func containsType(types []DatabaseType, dbType DatabaseType) bool {
+	for _, t := range types {
+		if t == dbType {
+			return true
+		}
+	}
+	return false
+}
+
+// skipUnlessSupported skips the test when any of types is not compiled in
+func skipUnlessSupported(t *testing.T, types ...DatabaseType) {
+	t.Helper()
+	for _, dbType := range types {
+		if !containsType(SupportedTypes(), dbType) {
+			t.Skipf("%s support not compiled in", dbType)
+		}
+	}
+}
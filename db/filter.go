@@ -0,0 +1,316 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidFilter is returned by ParseFilter for malformed or unknown filters
+var ErrInvalidFilter = errors.New("db: invalid filter")
+
+// maxFilterValues limits the number of values of an in filter
+const maxFilterValues = 100
+
+// FilterOp is a filter comparison operator
+type FilterOp string
+
+const (
+	// OpEq matches values equal to the filter value
+	OpEq FilterOp = "eq"
+	// OpNe matches values not equal to the filter value
+	OpNe FilterOp = "ne"
+	// OpGt matches values greater than the filter value
+	OpGt FilterOp = "gt"
+	// OpGte matches values greater than or equal to the filter value
+	OpGte FilterOp = "gte"
+	// OpLt matches values less than the filter value
+	OpLt FilterOp = "lt"
+	// OpLte matches values less than or equal to the filter value
+	OpLte FilterOp = "lte"
+	// OpIn matches any of the comma-separated filter values
+	OpIn FilterOp = "in"
+	// OpLike matches a pattern where * matches any sequence of characters
+	OpLike FilterOp = "like"
+	// OpNull matches NULL values when the filter value is true, and other values when false
+	OpNull FilterOp = "null"
+)
+
+// FieldType is the type of a filterable field, which determines how filter
+// values are parsed and which operators apply
+type FieldType string
+
+const (
+	// FieldString supports eq, ne, in, like and null
+	FieldString FieldType = "string"
+	// FieldInt supports eq, ne, gt, gte, lt, lte, in and null
+	FieldInt FieldType = "int"
+	// FieldFloat supports eq, ne, gt, gte, lt, lte, in and null
+	FieldFloat FieldType = "float"
+	// FieldBool supports eq, ne and null
+	FieldBool FieldType = "bool"
+	// FieldTime accepts RFC 3339 timestamps or dates (2006-01-02) and supports
+	// eq, ne, gt, gte, lt, lte, in and null
+	FieldTime FieldType = "time"
+)
+
+// fieldTypeOps lists the operators supported by each field type
+var fieldTypeOps = map[FieldType][]FilterOp{
+	FieldString: {OpEq, OpNe, OpIn, OpLike, OpNull},
+	FieldInt:    {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpNull},
+	FieldFloat:  {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpNull},
+	FieldBool:   {OpEq, OpNe, OpNull},
+	FieldTime:   {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpNull},
+}
+
+// FilterField describes a filterable API field
+type FilterField struct {
+	// Column is the database column the field maps to
+	Column string
+	// Type is the field type
+	Type FieldType
+	// Ops restricts the operators allowed on the field (default: every operator of Type)
+	Ops []FilterOp
+}
+
+// allows reports whether op can be used on the field
+func (f FilterField) allows(op FilterOp) bool {
+	if len(f.Ops) > 0 && !containsOp(f.Ops, op) {
+		return false
+	}
+	return containsOp(fieldTypeOps[f.Type], op)
+}
+
+// containsOp reports whether ops contains op
+func containsOp(ops []FilterOp, op FilterOp) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterFields maps API field names to their filterable columns
+type FilterFields map[string]FilterField
+
+// Condition is one parsed filter term: Field Op Values
+type Condition struct {
+	// Field is the API field name
+	Field string
+	// Column is the database column the field maps to
+	Column string
+	// Op is the comparison operator
+	Op FilterOp
+	// Values holds the parsed values: one for most operators, several for OpIn.
+	// Their types follow the field type: string, int64, float64, bool or time.Time.
+	// OpLike holds the LIKE pattern and OpNull a bool.
+	Values []any
+}
+
+// Expression compiles the condition to a parameterized clause.Expression
+func (c Condition) Expression() clause.Expression {
+	column := clause.Column{Name: c.Column}
+	switch c.Op {
+	case OpNe:
+		return clause.Neq{Column: column, Value: c.Values[0]}
+	case OpGt:
+		return clause.Gt{Column: column, Value: c.Values[0]}
+	case OpGte:
+		return clause.Gte{Column: column, Value: c.Values[0]}
+	case OpLt:
+		return clause.Lt{Column: column, Value: c.Values[0]}
+	case OpLte:
+		return clause.Lte{Column: column, Value: c.Values[0]}
+	case OpIn:
+		return clause.IN{Column: column, Values: c.Values}
+	case OpLike:
+		return clause.Expr{SQL: "? LIKE ? ESCAPE '!'", Vars: []any{column, c.Values[0]}}
+	case OpNull:
+		if c.Values[0] == true {
+			return clause.Expr{SQL: "? IS NULL", Vars: []any{column}}
+		}
+		return clause.Expr{SQL: "? IS NOT NULL", Vars: []any{column}}
+	default:
+		return clause.Eq{Column: column, Value: c.Values[0]}
+	}
+}
+
+// Filter is a validated list of conditions that must all match, applied with FilterScope
+type Filter []Condition
+
+// Expression compiles the filter to a parameterized clause.Expression
+func (f Filter) Expression() clause.Expression {
+	exprs := make([]clause.Expression, len(f))
+	for i, c := range f {
+		exprs[i] = c.Expression()
+	}
+	return clause.And(exprs...)
+}
+
+// filterKeyRegexp matches query keys such as status[eq]
+var filterKeyRegexp = regexp.MustCompile(`^([A-Za-z0-9_.]+)\[([a-z]+)\]$`)
+
+// ParseFilter parses query-string filters such as status[eq]=active,
+// id[in]=1,2,3, created_at[gte]=2024-01-01 or name[like]=jo* into a Filter.
+// A key without an operator, such as status=active, is an eq filter on a
+// known field. Keys that are neither filters nor known fields, such as page or
+// sort, are ignored. Only columns from fields reach the SQL, and values are
+// passed as parameters, so values may come from a query string.
+//
+// Parameters:
+// - values: the query values, e.g. r.URL.Query().
+// - fields: the filterable API fields.
+//
+// Returns:
+// - Filter: the parsed filter, ordered by field name.
+// - error: an error wrapping ErrInvalidFilter if a filter is malformed, its field
+// is unknown, its operator is not allowed or a value does not match the field type.
+func ParseFilter(values url.Values, fields FilterFields) (Filter, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var filter Filter
+	for _, key := range keys {
+		name, op := key, OpEq
+		if match := filterKeyRegexp.FindStringSubmatch(key); match != nil {
+			name, op = match[1], FilterOp(match[2])
+			if _, ok := fields[name]; !ok {
+				return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, name)
+			}
+		}
+		field, ok := fields[name]
+		if !ok {
+			continue
+		}
+		if !field.allows(op) {
+			return nil, fmt.Errorf("%w: operator %q is not allowed on field %q", ErrInvalidFilter, op, name)
+		}
+
+		for _, raw := range values[key] {
+			parsed, err := parseFilterValues(field.Type, op, raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: field %q: %w", ErrInvalidFilter, name, err)
+			}
+			filter = append(filter, Condition{Field: name, Column: field.Column, Op: op, Values: parsed})
+		}
+	}
+	return filter, nil
+}
+
+// parseFilterValues parses the raw value of a filter for the operator and field type
+func parseFilterValues(t FieldType, op FilterOp, raw string) ([]any, error) {
+	switch op {
+	case OpNull:
+		isNull, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid null value %q", raw)
+		}
+		return []any{isNull}, nil
+	case OpLike:
+		return []any{likePattern(raw)}, nil
+	case OpIn:
+		parts := strings.Split(raw, ",")
+		if len(parts) > maxFilterValues {
+			return nil, fmt.Errorf("too many values (max %d)", maxFilterValues)
+		}
+		values := make([]any, len(parts))
+		for i, part := range parts {
+			v, err := parseFilterValue(t, strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	default:
+		v, err := parseFilterValue(t, raw)
+		if err != nil {
+			return nil, err
+		}
+		return []any{v}, nil
+	}
+}
+
+// parseFilterValue parses a single value of the field type
+func parseFilterValue(t FieldType, raw string) (any, error) {
+	switch t {
+	case FieldInt:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", raw)
+		}
+		return v, nil
+	case FieldFloat:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", raw)
+		}
+		return v, nil
+	case FieldBool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean %q", raw)
+		}
+		return v, nil
+	case FieldTime:
+		if v, err := time.Parse(time.RFC3339, raw); err == nil {
+			return v, nil
+		}
+		v, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q", raw)
+		}
+		return v, nil
+	default:
+		return raw, nil
+	}
+}
+
+// likeEscaper escapes LIKE wildcards with '!', the ESCAPE character used by
+// Condition.Expression. '[' is escaped for SQL Server, where it starts a
+// character class; the other databases match an escaped '[' literally.
+// ClickHouse has no ESCAPE clause, so FilterScope rejects like filters there.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_", "[", "![")
+
+// likePattern converts a filter value, where * matches any sequence of
+// characters, to a LIKE pattern
+func likePattern(raw string) string {
+	return strings.ReplaceAll(likeEscaper.Replace(raw), "*", "%")
+}
+
+// FilterScope returns a GORM scope adding the filter's conditions to the
+// WHERE clause, for use with db.Scopes
+//
+// Parameters:
+// - filter: the filter, typically from ParseFilter.
+//
+// Returns:
+// - func(*gorm.DB) *gorm.DB: the scope.
+func FilterScope(filter Filter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(filter) == 0 {
+			return db
+		}
+		if db.Dialector != nil && db.Dialector.Name() == "clickhouse" {
+			for _, c := range filter {
+				if c.Op == OpLike {
+					_ = db.AddError(fmt.Errorf("%w: operator %q is not supported on ClickHouse", ErrInvalidFilter, OpLike))
+					return db
+				}
+			}
+		}
+		return db.Clauses(clause.Where{Exprs: []clause.Expression{filter.Expression()}})
+	}
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gao/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var testFilterFields = FilterFields{
+	"name":    {Column: "name", Type: FieldString},
+	"age":     {Column: "age", Type: FieldInt},
+	"score":   {Column: "score", Type: FieldFloat},
+	"active":  {Column: "active", Type: FieldBool},
+	"created": {Column: "users.created_at", Type: FieldTime},
+	"email":   {Column: "email", Type: FieldString, Ops: []FilterOp{OpEq}},
+}
+
+func TestParseFilter(t *testing.T) {
+	testCases := []struct {
+		name   string
+		query  string
+		expect Filter
+		err    string
+	}{
+		{name: "empty", query: "page=2&sort=name"},
+		{
+			name:   "plain key is eq",
+			query:  "name=alice&page=2",
+			expect: Filter{{Field: "name", Column: "name", Op: OpEq, Values: []any{"alice"}}},
+		},
+		{
+			name:  "typed values",
+			query: "age[gte]=18&score[lt]=9.5&active[eq]=true&created[gt]=2024-01-31",
+			expect: Filter{
+				{Field: "active", Column: "active", Op: OpEq, Values: []any{true}},
+				{Field: "age", Column: "age", Op: OpGte, Values: []any{int64(18)}},
+				{Field: "created", Column: "users.created_at", Op: OpGt, Values: []any{time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)}},
+				{Field: "score", Column: "score", Op: OpLt, Values: []any{9.5}},
+			},
+		},
+		{
+			name:   "in",
+			query:  "age[in]=1, 2,3",
+			expect: Filter{{Field: "age", Column: "age", Op: OpIn, Values: []any{int64(1), int64(2), int64(3)}}},
+		},
+		{
+			name:   "like escapes wildcards",
+			query:  "name[like]=" + url.QueryEscape("a_b%c![x]*"),
+			expect: Filter{{Field: "name", Column: "name", Op: OpLike, Values: []any{"a!_b!%c!!![x]%"}}},
+		},
+		{
+			name:   "null",
+			query:  "name[null]=false",
+			expect: Filter{{Field: "name", Column: "name", Op: OpNull, Values: []any{false}}},
+		},
+		{
+			name:  "repeated key",
+			query: "age[gte]=18&age[gte]=21",
+			expect: Filter{
+				{Field: "age", Column: "age", Op: OpGte, Values: []any{int64(18)}},
+				{Field: "age", Column: "age", Op: OpGte, Values: []any{int64(21)}},
+			},
+		},
+		{name: "unknown field", query: "password[eq]=x", err: `unknown field "password"`},
+		{name: "unknown operator", query: "name[regex]=x", err: `operator "regex" is not allowed`},
+		{name: "operator not allowed for type", query: "active[gt]=true", err: `operator "gt" is not allowed on field "active"`},
+		{name: "operator not allowed for field", query: "email[like]=a*", err: `operator "like" is not allowed on field "email"`},
+		{name: "invalid integer", query: "age[eq]=1%3BDROP", err: `invalid integer "1;DROP"`},
+		{name: "invalid time", query: "created[gte]=yesterday", err: `invalid time "yesterday"`},
+		{name: "invalid null", query: "name[null]=maybe", err: `invalid null value "maybe"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+
+			filter, err := ParseFilter(values, testFilterFields)
+			if tc.err != "" {
+				assert.ErrorIs(t, err, ErrInvalidFilter)
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, filter)
+		})
+	}
+}
+
+func TestFilterScope(t *testing.T) {
+	manager := newTxManager(t)
+	values, err := url.ParseQuery("name[like]=a*&age[in]=1,2&created[null]=true&score[ne]=0")
+	require.NoError(t, err)
+	filter, err := ParseFilter(values, testFilterFields)
+	require.NoError(t, err)
+
+	query := manager.DB().ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Table("users").Scopes(FilterScope(filter)).Find(&[]map[string]any{})
+	})
+	assert.Equal(t, "SELECT * FROM `users` WHERE `age` IN (1,2) AND `users`.`created_at` IS NULL AND `name` LIKE \"a%\" ESCAPE '!' AND `score` <> 0", query)
+}
+
+func TestFilterScope_Query(t *testing.T) {
+	manager := newTxManager(t)
+	db := manager.DB()
+	require.NoError(t, db.Exec("CREATE TABLE users (name TEXT, age INTEGER, active BOOLEAN)").Error)
+	require.NoError(t, db.Exec(`INSERT INTO users VALUES
+		('alice', 30, true), ('al_x', 25, true), ('bob', 17, false), ('carol', 41, true), ('dave', NULL, true), ('[b]ob', 10, false)`).Error)
+
+	query := func(q string) []string {
+		t.Helper()
+		values, err := url.ParseQuery(q)
+		require.NoError(t, err)
+		filter, err := ParseFilter(values, testFilterFields)
+		require.NoError(t, err)
+		sort, err := ParseSort(values.Get("sort"), map[string]string{"name": "name", "age": "age"})
+		require.NoError(t, err)
+
+		var names []string
+		require.NoError(t, db.Table("users").
+			Scopes(FilterScope(filter), SortScope(sort), PagingScope(pagination.PagingOptions{Page: 1, PageSize: 2})).
+			Pluck("name", &names).Error)
+		return names
+	}
+
+	assert.Equal(t, []string{"al_x", "alice"}, query("age[gte]=18&active=true&sort=age"))
+	assert.Equal(t, []string{"al_x"}, query("name[like]=al_*"))
+	assert.Equal(t, []string{"[b]ob"}, query("name[like]="+url.QueryEscape("[b]*")))
+	assert.Equal(t, []string{"bob", "carol"}, query("name[in]=bob,carol,erin&sort=name"))
+	assert.Equal(t, []string{"dave"}, query("age[null]=true"))
+	assert.Equal(t, []string{"carol", "alice"}, query("age[null]=false&active[ne]=false&sort=age:desc"))
+}
+
+func TestPagingScope(t *testing.T) {
+	manager := newTxManager(t)
+
+	query := manager.DB().ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Table("users").Scopes(PagingScope(pagination.PagingOptions{Page: 3, PageSize: 20})).Find(&[]map[string]any{})
+	})
+	assert.Equal(t, "SELECT * FROM `users` LIMIT 20 OFFSET 40", query)
+}
+
+func TestPagingScope_Defaults(t *testing.T) {
+	manager := newTxManager(t)
+
+	query := manager.DB().ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Table("users").Scopes(PagingScope(pagination.PagingOptions{})).Find(&[]map[string]any{})
+	})
+	assert.Equal(t, "SELECT * FROM `users` LIMIT 20", query)
+}
+
+// clickHouseDialector reports the ClickHouse dialect name over another dialector
+type clickHouseDialector struct{ gorm.Dialector }
+
+func (clickHouseDialector) Name() string { return "clickhouse" }
+
+func TestFilterScope_ClickHouseLike(t *testing.T) {
+	manager := newTxManager(t)
+	sqlDB, err := manager.DB().DB()
+	require.NoError(t, err)
+	dialector, err := createConnDialector(SQLite, sqlDB)
+	require.NoError(t, err)
+	db, err := gorm.Open(clickHouseDialector{dialector}, &gorm.Config{DryRun: true})
+	require.NoError(t, err)
+
+	filter := Filter{{Field: "name", Column: "name", Op: OpLike, Values: []any{"a%"}}}
+	err = db.Table("users").Scopes(FilterScope(filter)).Find(&[]map[string]any{}).Error
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+	assert.ErrorContains(t, err, `operator "like" is not supported on ClickHouse`)
+
+	filter[0] = Condition{Field: "name", Column: "name", Op: OpEq, Values: []any{"a"}}
+	assert.NoError(t, db.Table("users").Scopes(FilterScope(filter)).Find(&[]map[string]any{}).Error)
+}
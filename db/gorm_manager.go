@@ -2,24 +2,44 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"sync"
 	"time"
 
+	"github.com/ducminhgd/gao/defaults"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"gorm.io/plugin/dbresolver"
 )
 
 type GORMManager struct {
-	db     *gorm.DB
-	config ManagerConfig
+	db      *gorm.DB
+	config  ManagerConfig
+	nodes   []*node
+	routers routers
+
+	monitorStop chan struct{}
+	monitorWG   sync.WaitGroup
 }
 
+// NoPoolLimit disables a PoolConfig limit; any negative value does the same,
+// e.g. -1s for a duration. Zero settings take their defaults in
+// NewGORMManagerFromConfig, so a negative value is how to ask for unlimited
+// open connections or connections that are never closed for their age.
+const NoPoolLimit = -1
+
+// PoolConfig configures the connection pool of a database connection
 type PoolConfig struct {
+	// ConnMaxIdleTime is how long a connection may be idle (NoPoolLimit: forever)
 	ConnMaxIdleTime time.Duration `default:"10m"`
+	// ConnMaxLifetime is how long a connection may be reused (NoPoolLimit: forever)
 	ConnMaxLifetime time.Duration `default:"60m"`
-	MaxIdleConns    int           `default:"5"`
-	MaxOpenConns    int           `default:"10"`
+	// MaxIdleConns is the number of idle connections kept (NoPoolLimit: none)
+	MaxIdleConns int `default:"5"`
+	// MaxOpenConns is the number of open connections allowed (NoPoolLimit: unlimited)
+	MaxOpenConns int `default:"10"`
 }
 
 // NewGORMManager creates a new GORMManager instance.
@@ -36,8 +56,14 @@ func NewGORMManager(d gorm.Dialector, opts ...gorm.Option) (*GORMManager, error)
 	if err != nil {
 		return nil, err
 	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		closeConnPool(db)
+		return nil, err
+	}
 	return &GORMManager{
-		db: db,
+		db:    db,
+		nodes: []*node{{role: RolePrimary, sqlDB: sqlDB}},
 	}, nil
 }
 
@@ -118,28 +144,42 @@ func (m *GORMManager) DB() *gorm.DB {
 	return m.db
 }
 
-// Close closes all database connections
+// Close closes all database connections. Every connection is closed even
+// if closing an earlier one fails.
 //
 // Returns:
-// - error: an error if closing fails
+// - error: the errors of the connections that failed to close, joined
 func (m *GORMManager) Close() error {
-	sqlDB, err := m.db.DB()
-	if err != nil {
-		return fmt.Errorf("failed to get sql.DB: %w", err)
+	m.stopMonitors()
+
+	// The primary and the sources and replicas opened from the config
+	var errs []error
+	for _, n := range m.nodes {
+		if err := n.sqlDB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s: %w", n.name(), err))
+		}
 	}
-	return sqlDB.Close()
+	return errors.Join(errs...)
 }
 
 // NewGORMManagerFromConfig creates a new GORMManager with the provided configuration.
 // This function supports MySQL and PostgreSQL databases with multiple sources and replicas.
 //
 // Parameters:
-// - config: the ManagerConfig containing database configuration
+// - config: the ManagerConfig containing database configuration; zero pool
+// settings take the `default` tags of PoolConfig
 //
 // Returns:
 // - *GORMManager: the newly created GORMManager instance
 // - error: an error if the manager creation fails
 func NewGORMManagerFromConfig(config ManagerConfig) (*GORMManager, error) {
+	// Fill unset settings, such as PoolConfig, from their `default` tags on a
+	// copy so that the caller's slices and maps are not modified
+	config = config.clone()
+	if err := defaults.Apply(&config); err != nil {
+		return nil, err
+	}
+
 	// Create the primary database connection
 	dialector, err := createDialector(config.Primary)
 	if err != nil {
@@ -161,6 +201,7 @@ func NewGORMManagerFromConfig(config ManagerConfig) (*GORMManager, error) {
 	// Apply pool configuration to primary database
 	sqlDB, err := db.DB()
 	if err != nil {
+		closeConnPool(db)
 		return nil, fmt.Errorf("failed to get sql.DB from primary: %w", err)
 	}
 	applyPoolConfig(sqlDB, config.Primary.PoolConfig)
@@ -168,89 +209,204 @@ func NewGORMManagerFromConfig(config ManagerConfig) (*GORMManager, error) {
 	manager := &GORMManager{
 		db:     db,
 		config: config,
+		nodes:  []*node{{role: RolePrimary, config: config.Primary, sqlDB: sqlDB}},
 	}
 
-	// Register sources and replicas if provided
-	if len(config.Sources) > 0 || len(config.Replicas) > 0 {
+	// Register sources, replicas and clusters if provided
+	if len(config.Sources) > 0 || len(config.Replicas) > 0 || len(config.Clusters) > 0 {
 		if err := manager.registerSourcesAndReplicas(); err != nil {
+			manager.Close()
 			return nil, fmt.Errorf("failed to register sources/replicas: %w", err)
 		}
+		manager.startReplicaMonitor()
 	}
+	manager.startStatsReporter()
 
 	return manager, nil
 }
 
-// registerSourcesAndReplicas registers source and replica databases with dbresolver
+// registerSourcesAndReplicas registers source and replica databases, and those
+// of each cluster, with dbresolver
 func (m *GORMManager) registerSourcesAndReplicas() error {
-	var sources []gorm.Dialector
-	var replicas []gorm.Dialector
+	resolver := &dbresolver.DBResolver{}
 
-	// Create dialectors for sources
-	for _, sourceConfig := range m.config.Sources {
-		dialector, err := createDialector(sourceConfig)
+	if len(m.config.Sources) > 0 || len(m.config.Replicas) > 0 {
+		resolverConfig, err := m.openGroup("", m.config.Sources, m.config.Replicas, m.config.Policy)
 		if err != nil {
-			return fmt.Errorf("failed to create source dialector: %w", err)
+			return err
 		}
-		sources = append(sources, dialector)
+		resolver.Register(resolverConfig)
 	}
 
-	// Create dialectors for replicas
-	for _, replicaConfig := range m.config.Replicas {
-		dialector, err := createDialector(replicaConfig)
+	for _, name := range m.clusterNames() {
+		cluster := m.config.Clusters[name]
+		if len(cluster.Sources) == 0 && len(cluster.Replicas) == 0 {
+			return fmt.Errorf("cluster %s has no sources or replicas", name)
+		}
+		policy := cluster.Policy
+		if policy == "" {
+			policy = m.config.Policy
+		}
+		resolverConfig, err := m.openGroup(name, cluster.Sources, cluster.Replicas, policy)
 		if err != nil {
-			return fmt.Errorf("failed to create replica dialector: %w", err)
+			return fmt.Errorf("cluster %s: %w", name, err)
 		}
-		replicas = append(replicas, dialector)
+		resolver.Register(resolverConfig, cluster.datas(name)...)
 	}
 
-	// Register with dbresolver
-	resolverConfig := dbresolver.Config{
-		Sources:  sources,
-		Replicas: replicas,
-		Policy:   dbresolver.RandomPolicy{},
+	// Pool configuration is applied to each node by openNode rather than on the
+	// resolver, which would apply one config to every source, replica and the primary
+	if err := m.db.Use(resolver); err != nil {
+		return err
 	}
+	if m.config.StickyPrimaryWindow > 0 {
+		if err := (stickyRouter{window: m.config.StickyPrimaryWindow}).register(m.db); err != nil {
+			return err
+		}
+	}
+	return m.routers.register(m.db)
+}
 
-	resolver := dbresolver.Register(resolverConfig)
+// openGroup opens the sources and replicas of the default group (empty cluster
+// name) or of a cluster, and returns the dbresolver config routing between them.
+// Reads skip replicas that are out of rotation.
+func (m *GORMManager) openGroup(cluster string, sourceConfigs, replicaConfigs []DBConfig, policy LoadBalancePolicy) (dbresolver.Config, error) {
+	var sources, replicas []gorm.Dialector
+	var sourceNodes, replicaNodes []*node
 
-	// Apply pool configuration for sources
-	for _, sourceConfig := range m.config.Sources {
-		resolver = resolver.SetConnMaxIdleTime(sourceConfig.PoolConfig.ConnMaxIdleTime).
-			SetConnMaxLifetime(sourceConfig.PoolConfig.ConnMaxLifetime).
-			SetMaxIdleConns(sourceConfig.PoolConfig.MaxIdleConns).
-			SetMaxOpenConns(sourceConfig.PoolConfig.MaxOpenConns)
+	// Open sources, keeping their connection pools for health checks
+	for i, sourceConfig := range sourceConfigs {
+		n, err := m.openNode(cluster, RoleSource, i, sourceConfig)
+		if err != nil {
+			return dbresolver.Config{}, fmt.Errorf("failed to open source %d: %w", i, err)
+		}
+		sourceNodes = append(sourceNodes, n)
+		dialector, err := createConnDialector(sourceConfig.Type, n.sqlDB)
+		if err != nil {
+			return dbresolver.Config{}, fmt.Errorf("failed to open source %d: %w", i, err)
+		}
+		sources = append(sources, dialector)
 	}
 
-	// Apply pool configuration for replicas
-	for _, replicaConfig := range m.config.Replicas {
-		resolver = resolver.SetConnMaxIdleTime(replicaConfig.PoolConfig.ConnMaxIdleTime).
-			SetConnMaxLifetime(replicaConfig.PoolConfig.ConnMaxLifetime).
-			SetMaxIdleConns(replicaConfig.PoolConfig.MaxIdleConns).
-			SetMaxOpenConns(replicaConfig.PoolConfig.MaxOpenConns)
+	// Open replicas, keeping their connection pools for health checks
+	for i, replicaConfig := range replicaConfigs {
+		n, err := m.openNode(cluster, RoleReplica, i, replicaConfig)
+		if err != nil {
+			return dbresolver.Config{}, fmt.Errorf("failed to open replica %d: %w", i, err)
+		}
+		replicaNodes = append(replicaNodes, n)
+		dialector, err := createConnDialector(replicaConfig.Type, n.sqlDB)
+		if err != nil {
+			return dbresolver.Config{}, fmt.Errorf("failed to open replica %d: %w", i, err)
+		}
+		replicas = append(replicas, dialector)
+	}
+
+	// Without sources, dbresolver writes to the primary, so reads fall back to it too
+	if len(sourceNodes) == 0 {
+		sourceNodes = m.nodes[:1]
+	}
+	router, err := newReplicaRouter(sourceNodes, replicaNodes, policy)
+	if err != nil {
+		return dbresolver.Config{}, err
 	}
+	m.routers = append(m.routers, router)
 
-	return m.db.Use(resolver)
+	return dbresolver.Config{
+		Sources:  sources,
+		Replicas: replicas,
+		Policy:   router,
+	}, nil
 }
 
-// createDialector creates a GORM dialector based on the database type and DSN
+// createDialector creates a GORM dialector based on the database type and DSN.
+// The type is checked first, so that a driver excluded by build tags is
+// reported with ErrDriverNotCompiled rather than as an invalid DSN.
 func createDialector(config DBConfig) (gorm.Dialector, error) {
-	dsn := config.GetDSN()
+	if err := driverError(config.Type); err != nil {
+		return nil, err
+	}
+
+	dsn, err := config.BuildDSN()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build DSN: %w", err)
+	}
 	if dsn == "" {
 		return nil, fmt.Errorf("DSN is empty for database type: %s", config.Type)
 	}
 
 	switch config.Type {
 	case MySQL:
-		return newMySQLDialector(dsn), nil
+		return newMySQLDialector(dsn)
+	case PostgreSQL:
+		return newPostgreSQLDialector(dsn)
+	case SQLServer:
+		return newSQLServerDialector(dsn)
+	case ClickHouse:
+		return newClickHouseDialector(dsn)
+	default:
+		return newSQLiteDialector(dsn)
+	}
+}
+
+// createConnDialector creates a GORM dialector that reuses an open connection pool
+func createConnDialector(dbType DatabaseType, conn gorm.ConnPool) (gorm.Dialector, error) {
+	switch dbType {
+	case MySQL:
+		return newMySQLConnDialector(conn)
 	case PostgreSQL:
-		return newPostgreSQLDialector(dsn), nil
+		return newPostgreSQLConnDialector(conn)
 	case SQLite:
-		return newSQLiteDialector(dsn), nil
+		return newSQLiteConnDialector(conn)
+	case SQLServer:
+		return newSQLServerConnDialector(conn)
+	case ClickHouse:
+		return newClickHouseConnDialector(conn)
 	default:
-		return nil, fmt.Errorf("unsupported database type: %s", config.Type)
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+}
+
+// openNode opens a source or replica connection with its own pool configuration
+// and records it on the manager
+func (m *GORMManager) openNode(cluster string, role NodeRole, index int, config DBConfig) (*node, error) {
+	dialector, err := createDialector(config)
+	if err != nil {
+		return nil, err
+	}
+
+	gormConfig := &gorm.Config{}
+	if m.config.GormConfig != nil {
+		gormConfig.Logger = m.config.GormConfig.Logger
+	}
+
+	db, err := gorm.Open(dialector, gormConfig)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		closeConnPool(db)
+		return nil, err
+	}
+
+	applyPoolConfig(sqlDB, config.PoolConfig)
+
+	n := &node{role: role, index: index, cluster: cluster, config: config, sqlDB: sqlDB}
+	m.nodes = append(m.nodes, n)
+	return n, nil
+}
+
+// closeConnPool closes the connection pool of a gorm.DB whose sql.DB is not
+// available, so that a failed open does not leak it
+func closeConnPool(db *gorm.DB) {
+	if closer, ok := db.ConnPool.(io.Closer); ok {
+		_ = closer.Close()
 	}
 }
 
-// applyPoolConfig applies connection pool configuration to sql.DB
+// applyPoolConfig applies connection pool configuration to sql.DB. sql.DB
+// treats negative values like zero: no limit, or no idle connections for MaxIdleConns.
 func applyPoolConfig(sqlDB *sql.DB, config PoolConfig) {
 	sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
 	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingConnector is a driver.Connector whose Close fails
+type failingConnector struct{}
+
+func (failingConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("not connected")
+}
+
+func (failingConnector) Driver() driver.Driver { return nil }
+
+func (failingConnector) Close() error { return errors.New("close failed") }
+
+func TestGORMManager_Close(t *testing.T) {
+	manager := newSQLiteReadWriteManager(t, 1, 1)
+	manager.nodes[0].sqlDB = sql.OpenDB(failingConnector{})
+
+	err := manager.Close()
+	assert.ErrorContains(t, err, "failed to close primary: close failed")
+	for _, n := range manager.nodes[1:] {
+		assert.ErrorContains(t, n.sqlDB.Ping(), "database is closed", "%s is closed after the primary fails", n.name())
+	}
+}
+
+// closingConnPool is a connection pool that is not a *sql.DB, so that
+// gorm.DB.DB fails, and records whether it was closed
+type closingConnPool struct {
+	*sql.DB
+	closed bool
+}
+
+func (p *closingConnPool) Close() error {
+	p.closed = true
+	return p.DB.Close()
+}
+
+func TestNewGORMManager_ClosesConnPool(t *testing.T) {
+	dialector, err := createDialector(sqliteConfig(t, t.TempDir(), "primary"))
+	require.NoError(t, err)
+	manager, err := NewGORMManager(dialector)
+	require.NoError(t, err)
+	pool := &closingConnPool{DB: manager.nodes[0].sqlDB}
+	connDialector, err := createConnDialector(SQLite, pool)
+	require.NoError(t, err)
+
+	_, err = NewGORMManager(connDialector)
+	require.Error(t, err)
+	assert.True(t, pool.closed, "the opened connection is closed when sql.DB is unavailable")
+}
@@ -0,0 +1,268 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ducminhgd/gao/log"
+)
+
+// defaultHealthCheckTimeout bounds each ping when HealthCheckConfig.Timeout is not set
+const defaultHealthCheckTimeout = 2 * time.Second
+
+// HealthCheckConfig configures health checking of the manager's connections
+type HealthCheckConfig struct {
+	// Timeout bounds the ping of each connection (default 2s)
+	Timeout time.Duration
+
+	// Interval enables periodic replica checks when positive. Replicas failing
+	// FailureThreshold consecutive checks are removed from rotation and
+	// re-admitted after SuccessThreshold consecutive successful checks.
+	Interval time.Duration
+	// FailureThreshold is the number of failed checks before eviction (default 1)
+	FailureThreshold int
+	// SuccessThreshold is the number of successful checks before re-admission (default 1)
+	SuccessThreshold int
+}
+
+// withDefaults returns the config with zero values replaced by defaults
+func (c HealthCheckConfig) withDefaults() HealthCheckConfig {
+	if c.Timeout <= 0 {
+		c.Timeout = defaultHealthCheckTimeout
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 1
+	}
+	if c.SuccessThreshold <= 0 {
+		c.SuccessThreshold = 1
+	}
+	return c
+}
+
+// NodeHealth is the health of a single database connection
+type NodeHealth struct {
+	// Name identifies the connection, e.g. "primary", "source-0" or "replica-1"
+	Name string `json:"name"`
+	// Role is the role of the connection
+	Role NodeRole `json:"role"`
+	// Healthy reports whether the ping succeeded
+	Healthy bool `json:"healthy"`
+	// Latency is the duration of the ping
+	Latency time.Duration `json:"latency_ns"`
+	// Error is the ping error, if any
+	Error string `json:"error,omitempty"`
+}
+
+// HealthReport is the result of GORMManager.HealthCheck
+type HealthReport struct {
+	// Healthy reports whether the primary and every source are healthy. Reads
+	// fall back to the sources when replicas fail, so replicas do not count.
+	Healthy bool `json:"healthy"`
+	// Degraded reports whether any replica is unhealthy
+	Degraded bool `json:"degraded"`
+	// Nodes contains the health of each connection, primary first
+	Nodes []NodeHealth `json:"nodes"`
+}
+
+// HealthCheck pings the primary, every source and every replica concurrently.
+// Each ping is bounded by ManagerConfig.HealthCheck.Timeout and by ctx.
+//
+// Parameters:
+// - ctx: the context for the pings.
+//
+// Returns:
+// - HealthReport: the status and latency of every connection.
+func (m *GORMManager) HealthCheck(ctx context.Context) HealthReport {
+	timeout := m.config.HealthCheck.withDefaults().Timeout
+
+	report := HealthReport{
+		Healthy: true,
+		Nodes:   make([]NodeHealth, len(m.nodes)),
+	}
+
+	var wg sync.WaitGroup
+	for i, n := range m.nodes {
+		wg.Add(1)
+		go func(i int, n *node) {
+			defer wg.Done()
+			report.Nodes[i] = n.ping(ctx, timeout)
+		}(i, n)
+	}
+	wg.Wait()
+
+	for _, nh := range report.Nodes {
+		switch {
+		case nh.Healthy:
+		case nh.Role == RoleReplica:
+			report.Degraded = true
+		default:
+			report.Healthy = false
+		}
+	}
+	return report
+}
+
+// ping checks the connection and measures its latency
+func (n *node) ping(ctx context.Context, timeout time.Duration) NodeHealth {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := n.sqlDB.PingContext(ctx)
+
+	nh := NodeHealth{
+		Name:    n.name(),
+		Role:    n.role,
+		Healthy: err == nil,
+		Latency: time.Since(start),
+	}
+	if err != nil {
+		nh.Error = err.Error()
+	}
+	return nh
+}
+
+// HealthHandler returns an http.Handler suitable for Kubernetes readiness probes.
+// It responds with the JSON HealthReport and status 200 when the primary and
+// every source are healthy, or 503 Service Unavailable otherwise. Unhealthy
+// replicas only set Degraded, so that an outage of a replica shared by every
+// instance does not take them all out of service.
+func (m *GORMManager) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := m.HealthCheck(r.Context())
+
+		w.Header().Set("Content-Type", "application/json")
+		if report.Healthy {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// startReplicaMonitor starts the periodic replica health and lag checks if configured
+func (m *GORMManager) startReplicaMonitor() {
+	if len(m.replicaNodes()) == 0 {
+		return
+	}
+
+	healthInterval := m.config.HealthCheck.Interval
+	lagConfig := m.config.ReplicaLag.withDefaults()
+	if healthInterval <= 0 && lagConfig.MaxLag <= 0 {
+		return
+	}
+
+	if healthInterval > 0 {
+		m.runEvery(healthInterval, m.checkReplicas)
+	}
+	if lagConfig.MaxLag > 0 {
+		m.runEvery(lagConfig.Interval, m.checkReplicaLag)
+	}
+}
+
+// runEvery calls fn at every interval until the monitors are stopped
+func (m *GORMManager) runEvery(interval time.Duration, fn func(ctx context.Context)) {
+	if m.monitorStop == nil {
+		m.monitorStop = make(chan struct{})
+	}
+	m.monitorWG.Add(1)
+	go func() {
+		defer m.monitorWG.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.monitorStop:
+				return
+			case <-ticker.C:
+				fn(context.Background())
+			}
+		}
+	}()
+}
+
+// stopMonitors stops the periodic replica checks and stats reports and waits
+// for them to end
+func (m *GORMManager) stopMonitors() {
+	if m.monitorStop == nil {
+		return
+	}
+	close(m.monitorStop)
+	m.monitorWG.Wait()
+	m.monitorStop = nil
+}
+
+// replicaNodes returns the manager's replica nodes
+func (m *GORMManager) replicaNodes() []*node {
+	var replicas []*node
+	for _, n := range m.nodes {
+		if n.role == RoleReplica {
+			replicas = append(replicas, n)
+		}
+	}
+	return replicas
+}
+
+// checkReplicas pings every replica once and updates its rotation state
+func (m *GORMManager) checkReplicas(ctx context.Context) {
+	config := m.config.HealthCheck.withDefaults()
+	replicas := m.replicaNodes()
+
+	results := make([]NodeHealth, len(replicas))
+	var wg sync.WaitGroup
+	for i, n := range replicas {
+		wg.Add(1)
+		go func(i int, n *node) {
+			defer wg.Done()
+			results[i] = n.ping(ctx, config.Timeout)
+		}(i, n)
+	}
+	wg.Wait()
+
+	logger := m.logger()
+	changed := false
+	for i, n := range replicas {
+		nh := results[i]
+		if nh.Healthy {
+			n.failures = 0
+			n.successes++
+			if n.evicted.Load() && n.successes >= config.SuccessThreshold {
+				n.evicted.Store(false)
+				changed = true
+				logger.Info("database replica re-admitted",
+					log.Field{Key: "replica", Value: n.name()},
+					log.Field{Key: "latency", Value: nh.Latency},
+				)
+			}
+			continue
+		}
+
+		n.successes = 0
+		n.failures++
+		if !n.evicted.Load() && n.failures >= config.FailureThreshold {
+			n.evicted.Store(true)
+			changed = true
+			logger.Warn("database replica evicted",
+				log.Field{Key: "replica", Value: n.name()},
+				log.Field{Key: "error", Value: nh.Error},
+			)
+		}
+	}
+
+	if !changed {
+		return
+	}
+	for _, n := range replicas {
+		if n.available() {
+			return
+		}
+	}
+	logger.Error("all database replicas unavailable, reads fall back to sources",
+		log.Field{Key: "replicas", Value: len(replicas)},
+	)
+}
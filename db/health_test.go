@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sqliteConfig returns a DBConfig for a SQLite file in dir. The test is
+// skipped when SQLite support is not compiled in.
+func sqliteConfig(t *testing.T, dir, name string) DBConfig {
+	t.Helper()
+	skipUnlessSupported(t, SQLite)
+	return DBConfig{
+		Type:       SQLite,
+		Database:   filepath.Join(dir, name+".db"),
+		PoolConfig: DefaultPoolConfig(),
+	}
+}
+
+// newSQLiteReadWriteManager creates a manager with SQLite files standing in
+// for the primary, sources and replicas
+func newSQLiteReadWriteManager(t *testing.T, sources, replicas int) *GORMManager {
+	t.Helper()
+	dir := t.TempDir()
+
+	config := ManagerConfig{Primary: sqliteConfig(t, dir, "primary")}
+	for i := 0; i < sources; i++ {
+		config.Sources = append(config.Sources, sqliteConfig(t, dir, fmt.Sprintf("source%d", i)))
+	}
+	for i := 0; i < replicas; i++ {
+		config.Replicas = append(config.Replicas, sqliteConfig(t, dir, fmt.Sprintf("replica%d", i)))
+	}
+
+	manager, err := NewGORMManagerFromConfig(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+	return manager
+}
+
+func TestHealthCheck(t *testing.T) {
+	manager := newSQLiteReadWriteManager(t, 1, 2)
+
+	report := manager.HealthCheck(context.Background())
+
+	assert.True(t, report.Healthy)
+	assert.False(t, report.Degraded)
+	require.Len(t, report.Nodes, 4)
+	assert.Equal(t, "primary", report.Nodes[0].Name)
+	assert.Equal(t, RolePrimary, report.Nodes[0].Role)
+	assert.Equal(t, "source-0", report.Nodes[1].Name)
+	assert.Equal(t, RoleSource, report.Nodes[1].Role)
+	assert.Equal(t, "replica-0", report.Nodes[2].Name)
+	assert.Equal(t, "replica-1", report.Nodes[3].Name)
+	for _, nh := range report.Nodes {
+		assert.True(t, nh.Healthy, nh.Name)
+		assert.Empty(t, nh.Error, nh.Name)
+		assert.Positive(t, int64(nh.Latency), nh.Name)
+	}
+}
+
+func TestHealthCheck_UnhealthyReplica(t *testing.T) {
+	manager := newSQLiteReadWriteManager(t, 0, 2)
+	require.NoError(t, manager.nodes[2].sqlDB.Close())
+
+	report := manager.HealthCheck(context.Background())
+
+	assert.True(t, report.Healthy, "replicas do not affect the status")
+	assert.True(t, report.Degraded)
+	assert.True(t, report.Nodes[0].Healthy)
+	assert.True(t, report.Nodes[1].Healthy)
+	assert.False(t, report.Nodes[2].Healthy)
+	assert.NotEmpty(t, report.Nodes[2].Error)
+}
+
+func TestHealthCheck_NewGORMManager(t *testing.T) {
+	skipUnlessSupported(t, SQLite)
+	dialector, err := newSQLiteDialector(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	manager, err := NewGORMManager(dialector)
+	require.NoError(t, err)
+	defer manager.Close()
+
+	report := manager.HealthCheck(context.Background())
+
+	assert.True(t, report.Healthy)
+	require.Len(t, report.Nodes, 1)
+	assert.Equal(t, RolePrimary, report.Nodes[0].Role)
+}
+
+func TestHealthHandler(t *testing.T) {
+	manager := newSQLiteReadWriteManager(t, 0, 1)
+	handler := manager.HealthHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var report HealthReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.True(t, report.Healthy)
+	assert.Len(t, report.Nodes, 2)
+
+	require.NoError(t, manager.nodes[1].sqlDB.Close())
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "an unhealthy replica degrades the report")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.True(t, report.Degraded)
+
+	require.NoError(t, manager.nodes[0].sqlDB.Close())
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
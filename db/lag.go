@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ducminhgd/gao/log"
+)
+
+// defaultLagCheckInterval is used when ReplicaLagConfig.Interval is not set
+const defaultLagCheckInterval = 5 * time.Second
+
+// ReplicaLagConfig configures replication lag checks. Replicas behind by more
+// than MaxLag are removed from rotation until they catch up.
+type ReplicaLagConfig struct {
+	// MaxLag is the largest acceptable replication lag; zero disables lag checks
+	MaxLag time.Duration
+	// Interval is how often the lag is measured (default 5s)
+	Interval time.Duration
+	// Query overrides the dialect's lag query. It must return a single
+	// numeric column holding the lag in seconds.
+	Query string
+}
+
+// withDefaults returns the config with zero values replaced by defaults
+func (c ReplicaLagConfig) withDefaults() ReplicaLagConfig {
+	if c.Interval <= 0 {
+		c.Interval = defaultLagCheckInterval
+	}
+	return c
+}
+
+// postgresLagQuery returns the time since the last replayed transaction. It
+// returns 0 on a primary, and on a replica that has replayed all the WAL it
+// received: while the primary has no writes the time since the last replayed
+// transaction keeps growing, although the replica is up to date.
+const postgresLagQuery = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM (now() - pg_last_xact_replay_timestamp())), 0)
+END`
+
+const (
+	// mysqlLagQuery reports replica status including Seconds_Behind_Source
+	// (MySQL 8.0.22+, MariaDB 10.5.1+)
+	mysqlLagQuery = "SHOW REPLICA STATUS"
+	// mysqlLegacyLagQuery reports replica status including Seconds_Behind_Master
+	// on servers that do not support mysqlLagQuery
+	mysqlLegacyLagQuery = "SHOW SLAVE STATUS"
+)
+
+// replicationLag measures how far the node is behind its source
+func (n *node) replicationLag(ctx context.Context, query string) (time.Duration, error) {
+	if query == "" && n.config.Type == MySQL {
+		return mysqlReplicationLag(ctx, n.sqlDB)
+	}
+	if query == "" {
+		query = lagQuery(n.config.Type)
+	}
+	if query == "" {
+		// Dialects without replication, such as SQLite, never lag
+		return 0, nil
+	}
+	return scanLagSeconds(n.sqlDB.QueryRowContext(ctx, query))
+}
+
+// lagQuery returns the single-column lag query of a dialect, or "" if it has
+// none. MySQL reports the lag in its replica status instead.
+func lagQuery(dbType DatabaseType) string {
+	if dbType == PostgreSQL {
+		return postgresLagQuery
+	}
+	return ""
+}
+
+// scanLagSeconds reads a lag in seconds from a single-column row
+func scanLagSeconds(row *sql.Row) (time.Duration, error) {
+	var seconds sql.NullFloat64
+	if err := row.Scan(&seconds); err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds.Float64 * float64(time.Second)), nil
+}
+
+// mysqlReplicationLag reads the lag from the replica status. Servers rejecting
+// SHOW REPLICA STATUS with a syntax error are queried with SHOW SLAVE STATUS.
+func mysqlReplicationLag(ctx context.Context, sqlDB *sql.DB) (time.Duration, error) {
+	rows, err := sqlDB.QueryContext(ctx, mysqlLagQuery)
+	if isMySQLSyntaxError(err) {
+		rows, err = sqlDB.QueryContext(ctx, mysqlLegacyLagQuery)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	return scanReplicaStatusLag(rows)
+}
+
+// scanReplicaStatusLag reads Seconds_Behind_Source (or Seconds_Behind_Master on
+// older servers) from replica status rows. A server that is not a replica has
+// no lag; a NULL value means replication is stopped and is reported as an error.
+func scanReplicaStatusLag(rows *sql.Rows) (time.Duration, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+		if values[i] == nil {
+			return 0, errors.New("replication is not running")
+		}
+		var seconds int64
+		if _, err := fmt.Sscan(string(values[i]), &seconds); err != nil {
+			return 0, fmt.Errorf("invalid %s value %q: %w", column, values[i], err)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, errors.New("replica status has no Seconds_Behind_Source column")
+}
+
+// checkReplicaLag measures the lag of every replica once and updates its rotation state.
+// Replicas whose lag cannot be measured are treated as lagging.
+func (m *GORMManager) checkReplicaLag(ctx context.Context) {
+	config := m.config.ReplicaLag.withDefaults()
+	timeout := m.config.HealthCheck.withDefaults().Timeout
+	replicas := m.replicaNodes()
+
+	lags := make([]time.Duration, len(replicas))
+	errs := make([]error, len(replicas))
+	var wg sync.WaitGroup
+	for i, n := range replicas {
+		wg.Add(1)
+		go func(i int, n *node) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			lags[i], errs[i] = n.replicationLag(ctx, config.Query)
+		}(i, n)
+	}
+	wg.Wait()
+
+	logger := m.logger()
+	for i, n := range replicas {
+		lagging := errs[i] != nil || lags[i] > config.MaxLag
+		if lagging == n.lagging.Load() {
+			continue
+		}
+		n.lagging.Store(lagging)
+
+		if !lagging {
+			logger.Info("database replica caught up",
+				log.Field{Key: "replica", Value: n.name()},
+				log.Field{Key: "lag", Value: lags[i]},
+			)
+			continue
+		}
+
+		fields := []log.Field{
+			{Key: "replica", Value: n.name()},
+			{Key: "max_lag", Value: config.MaxLag},
+		}
+		if errs[i] != nil {
+			fields = append(fields, log.Field{Key: "error", Value: errs[i].Error()})
+		} else {
+			fields = append(fields, log.Field{Key: "lag", Value: lags[i]})
+		}
+		logger.Warn("database replica lagging", fields...)
+	}
+}
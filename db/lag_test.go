@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gao/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckReplicaLag(t *testing.T) {
+	logger := &recordingLogger{}
+	manager := newSQLiteReadWriteManager(t, 0, 2)
+	manager.config.Logger = logger
+	manager.config.ReplicaLag = ReplicaLagConfig{MaxLag: time.Second, Query: "SELECT lag FROM replication_lag"}
+	seedNodes(t, manager)
+
+	replicas := manager.replicaNodes()
+	for i, lag := range []float64{10, 0.5} {
+		_, err := replicas[i].sqlDB.Exec("CREATE TABLE replication_lag (lag REAL)")
+		require.NoError(t, err)
+		_, err = replicas[i].sqlDB.Exec("INSERT INTO replication_lag (lag) VALUES (?)", lag)
+		require.NoError(t, err)
+	}
+
+	manager.checkReplicaLag(context.Background())
+	assert.False(t, replicas[0].available())
+	assert.True(t, replicas[1].available())
+	assert.Equal(t, []string{"database replica lagging"}, logger.Messages())
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "replica-1", readNode(t, manager))
+	}
+
+	_, err := replicas[0].sqlDB.Exec("UPDATE replication_lag SET lag = 0")
+	require.NoError(t, err)
+	manager.checkReplicaLag(context.Background())
+	assert.True(t, replicas[0].available())
+	assert.Equal(t, []string{"database replica lagging", "database replica caught up"}, logger.Messages())
+}
+
+func TestCheckReplicaLag_QueryError(t *testing.T) {
+	manager := newSQLiteReadWriteManager(t, 0, 1)
+	manager.config.Logger = log.NewNop()
+	manager.config.ReplicaLag = ReplicaLagConfig{MaxLag: time.Second, Query: "SELECT lag FROM missing_table"}
+
+	manager.checkReplicaLag(context.Background())
+	assert.False(t, manager.replicaNodes()[0].available())
+}
+
+func TestReplicationLag_SQLite(t *testing.T) {
+	manager := newSQLiteReadWriteManager(t, 0, 1)
+
+	lag, err := manager.replicaNodes()[0].replicationLag(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Zero(t, lag)
+}
+
+func TestLagQuery(t *testing.T) {
+	assert.Equal(t, postgresLagQuery, lagQuery(PostgreSQL))
+	// An idle replica that replayed all the WAL it received has no lag
+	assert.Contains(t, lagQuery(PostgreSQL), "pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0")
+	for _, dbType := range []DatabaseType{MySQL, SQLite, SQLServer, ClickHouse} {
+		assert.Empty(t, lagQuery(dbType), dbType)
+	}
+}
+
+func TestReplicaLagMonitor(t *testing.T) {
+	dir := t.TempDir()
+	manager, err := NewGORMManagerFromConfig(ManagerConfig{
+		Primary:    sqliteConfig(t, dir, "primary"),
+		Replicas:   []DBConfig{sqliteConfig(t, dir, "replica0")},
+		ReplicaLag: ReplicaLagConfig{MaxLag: time.Second, Interval: 5 * time.Millisecond, Query: "SELECT 60"},
+		Logger:     log.NewNop(),
+	})
+	require.NoError(t, err)
+	defer manager.Close()
+
+	replica := manager.replicaNodes()[0]
+	assert.Eventually(t, func() bool { return !replica.available() }, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestScanReplicaStatusLag(t *testing.T) {
+	manager := newSQLiteReadWriteManager(t, 0, 0)
+	sqlDB := manager.nodes[0].sqlDB
+
+	tests := []struct {
+		name     string
+		query    string
+		expected time.Duration
+		err      string
+	}{
+		{
+			name:     "Seconds_Behind_Source",
+			query:    "SELECT 'Yes' AS Replica_IO_Running, 7 AS Seconds_Behind_Source",
+			expected: 7 * time.Second,
+		},
+		{
+			name:     "Seconds_Behind_Master",
+			query:    "SELECT 'Yes' AS Slave_IO_Running, 3 AS Seconds_Behind_Master",
+			expected: 3 * time.Second,
+		},
+		{
+			name:  "replication stopped",
+			query: "SELECT 'No' AS Slave_IO_Running, NULL AS Seconds_Behind_Master",
+			err:   "replication is not running",
+		},
+		{
+			name:  "not a replica",
+			query: "SELECT 1 AS Seconds_Behind_Source WHERE 0",
+		},
+		{
+			name:  "no lag column",
+			query: "SELECT 'Yes' AS Slave_IO_Running",
+			err:   "no Seconds_Behind_Source column",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := sqlDB.Query(tt.query)
+			require.NoError(t, err)
+			defer rows.Close()
+
+			lag, err := scanReplicaStatusLag(rows)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, lag)
+		})
+	}
+}
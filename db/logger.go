@@ -0,0 +1,21 @@
+package db
+
+import "github.com/ducminhgd/gao/log"
+
+// orGlobalLogger returns l, or the global logger if l is nil
+func orGlobalLogger(l log.Logger) log.Logger {
+	if l != nil {
+		return l
+	}
+	return log.L()
+}
+
+// logger returns the configured logger, or the global logger
+func (m *GORMManager) logger() log.Logger {
+	return orGlobalLogger(m.config.Logger)
+}
+
+// logger returns the configured logger, or the global logger
+func (tm *TenantManager) logger() log.Logger {
+	return orGlobalLogger(tm.config.Logger)
+}
@@ -0,0 +1,599 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ducminhgd/gao/log"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultMigrationTable is used when MigrationConfig.Table is not set
+	defaultMigrationTable = "schema_migrations"
+	// defaultMigrationLockTimeout is used when MigrationConfig.LockTimeout is not set
+	defaultMigrationLockTimeout = time.Minute
+)
+
+// ErrMigrationLocked is returned when another instance holds the migration lock
+// for longer than MigrationConfig.LockTimeout
+var ErrMigrationLocked = errors.New("db: migration lock is held by another instance")
+
+// MigrationFunc applies or reverts a migration within a transaction
+type MigrationFunc func(ctx context.Context, tx *gorm.DB) error
+
+// Migration is a versioned schema change. It is defined either by Go functions
+// (Up and Down) or by SQL statements (UpSQL and DownSQL), as loaded from files
+// by SQLMigrations.
+type Migration struct {
+	// Version orders the migrations and must be unique and positive,
+	// e.g. 1, 2, 3 or a timestamp such as 20240131120000
+	Version int64
+	// Name describes the migration
+	Name string
+	// Up applies the migration
+	Up MigrationFunc
+	// Down reverts the migration (optional)
+	Down MigrationFunc
+	// UpSQL holds the statements applying the migration when Up is nil
+	UpSQL string
+	// DownSQL holds the statements reverting the migration when Down is nil
+	DownSQL string
+}
+
+// up returns the function applying the migration, or nil
+func (mg Migration) up() MigrationFunc {
+	if mg.Up != nil {
+		return mg.Up
+	}
+	return sqlMigrationFunc(mg.UpSQL)
+}
+
+// down returns the function reverting the migration, or nil
+func (mg Migration) down() MigrationFunc {
+	if mg.Down != nil {
+		return mg.Down
+	}
+	return sqlMigrationFunc(mg.DownSQL)
+}
+
+// sqlMigrationFunc returns a MigrationFunc executing the statements of a SQL script, or nil if it is empty
+func sqlMigrationFunc(script string) MigrationFunc {
+	statements := splitSQLStatements(script)
+	if len(statements) == 0 {
+		return nil
+	}
+	return func(ctx context.Context, tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// migrationFileRegexp matches migration file names such as 0001_create_users.up.sql
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// SQLMigrations loads migrations from SQL files named VERSION_NAME.up.sql and
+// VERSION_NAME.down.sql, e.g. 0001_create_users.up.sql. Other files are ignored.
+//
+// Parameters:
+// - fsys: the file system holding the files, typically an embed.FS.
+// - dir: the directory of the files within fsys ("." for the root).
+//
+// Returns:
+// - []Migration: the migrations ordered by version.
+// - error: an error if the files cannot be read or a down file has no up file.
+func SQLMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRegexp.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mg
+		} else if mg.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files with different names: %s and %s", version, mg.Name, match[2])
+		}
+		if match[3] == "up" {
+			mg.UpSQL = string(content)
+		} else {
+			mg.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.UpSQL == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mg.Version, mg.Name)
+		}
+		migrations = append(migrations, *mg)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrationConfig configures a MigrationRunner
+type MigrationConfig struct {
+	// Table records the applied migrations (default "schema_migrations")
+	Table string
+	// LockTimeout is how long to wait for another instance to finish migrating (default 1m)
+	LockTimeout time.Duration
+	// DryRun logs the migrations that would run without running them
+	DryRun bool
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	// Missing is set for applied versions that are not known to the runner
+	Missing bool `json:"missing,omitempty"`
+}
+
+// schemaMigration is a row of the migration table
+type schemaMigration struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255"`
+	AppliedAt time.Time
+}
+
+// MigrationRunner applies versioned migrations to a GORMManager's database.
+// Each migration runs in a transaction together with its record in the
+// migration table; MySQL commits DDL statements implicitly, so a failed MySQL
+// migration may be partially applied.
+//
+// Migrations run on a single connection to the first source of the default
+// group, or to the primary if there are no Sources. Up and Down hold a lock on
+// that connection while they run so that only one instance migrates:
+// pg_advisory_lock on PostgreSQL, GET_LOCK on MySQL and a lock file next to the
+// database file on SQLite.
+type MigrationRunner struct {
+	manager    *GORMManager
+	config     MigrationConfig
+	migrations []Migration
+}
+
+// NewMigrationRunner creates a MigrationRunner
+//
+// Parameters:
+// - manager: the GORMManager whose database is migrated.
+// - config: the MigrationConfig for the runner.
+// - migrations: the migrations, in any order.
+//
+// Returns:
+// - *MigrationRunner: the newly created runner.
+// - error: an error if a version is invalid or duplicated, or a migration has no up migration.
+func NewMigrationRunner(manager *GORMManager, config MigrationConfig, migrations ...Migration) (*MigrationRunner, error) {
+	if config.Table == "" {
+		config.Table = defaultMigrationTable
+	}
+	if config.LockTimeout <= 0 {
+		config.LockTimeout = defaultMigrationLockTimeout
+	}
+
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, mg := range sorted {
+		if mg.Version <= 0 {
+			return nil, fmt.Errorf("migration %s has invalid version %d", mg.Name, mg.Version)
+		}
+		if i > 0 && sorted[i-1].Version == mg.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", mg.Version)
+		}
+		if mg.up() == nil {
+			return nil, fmt.Errorf("migration %d_%s has no up migration", mg.Version, mg.Name)
+		}
+	}
+
+	return &MigrationRunner{
+		manager:    manager,
+		config:     config,
+		migrations: sorted,
+	}, nil
+}
+
+// Up applies every pending migration in version order
+//
+// Parameters:
+// - ctx: the context for the migrations.
+//
+// Returns:
+// - []Migration: the migrations applied, or that would be applied in dry-run mode.
+// - error: an error if the lock cannot be taken or released, or a migration fails.
+func (r *MigrationRunner) Up(ctx context.Context) (done []Migration, err error) {
+	session, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = errors.Join(err, session.release()) }()
+
+	if err := r.ensureTable(session.db); err != nil {
+		return nil, err
+	}
+	applied, err := r.applied(session.db)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mg := range r.migrations {
+		if _, ok := applied[mg.Version]; ok {
+			continue
+		}
+		if err := r.run(ctx, session.db, mg, true); err != nil {
+			return done, err
+		}
+		done = append(done, mg)
+	}
+	return done, nil
+}
+
+// Down reverts the most recently applied migrations
+//
+// Parameters:
+// - ctx: the context for the migrations.
+// - steps: the number of migrations to revert; 0 reverts none.
+//
+// Returns:
+// - []Migration: the migrations reverted, or that would be reverted in dry-run mode.
+// - error: an error if steps is negative, the lock cannot be taken or released,
+// a migration has no down migration or is unknown, or a migration fails.
+func (r *MigrationRunner) Down(ctx context.Context, steps int) (done []Migration, err error) {
+	if steps < 0 {
+		return nil, fmt.Errorf("invalid number of migrations to revert: %d", steps)
+	}
+
+	session, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = errors.Join(err, session.release()) }()
+
+	if err := r.ensureTable(session.db); err != nil {
+		return nil, err
+	}
+	applied, err := r.applied(session.db)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]int64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	if steps < len(versions) {
+		versions = versions[:steps]
+	}
+
+	for _, version := range versions {
+		mg, ok := r.find(version)
+		if !ok {
+			return done, fmt.Errorf("applied migration %d is unknown", version)
+		}
+		if mg.down() == nil {
+			return done, fmt.Errorf("migration %d_%s has no down migration", mg.Version, mg.Name)
+		}
+		if err := r.run(ctx, session.db, mg, false); err != nil {
+			return done, err
+		}
+		done = append(done, mg)
+	}
+	return done, nil
+}
+
+// Status reports every known migration and whether it has been applied,
+// followed by applied versions the runner does not know
+//
+// Parameters:
+// - ctx: the context for the query.
+//
+// Returns:
+// - []MigrationStatus: the status of each migration ordered by version.
+// - error: an error if the migration table cannot be read.
+func (r *MigrationRunner) Status(ctx context.Context) (statuses []MigrationStatus, err error) {
+	session, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = errors.Join(err, session.release()) }()
+
+	applied, err := r.applied(session.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses = make([]MigrationStatus, 0, len(r.migrations))
+	for _, mg := range r.migrations {
+		status := MigrationStatus{Version: mg.Version, Name: mg.Name}
+		if row, ok := applied[mg.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			delete(applied, mg.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, MigrationStatus{
+			Version:   row.Version,
+			Name:      row.Name,
+			Applied:   true,
+			AppliedAt: &appliedAt,
+			Missing:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// run applies or reverts a migration on db and records it in the migration table
+func (r *MigrationRunner) run(ctx context.Context, db *gorm.DB, mg Migration, up bool) error {
+	direction, fn := "up", mg.up()
+	if !up {
+		direction, fn = "down", mg.down()
+	}
+	logger := r.manager.logger()
+	fields := []log.Field{
+		{Key: "version", Value: mg.Version},
+		{Key: "name", Value: mg.Name},
+		{Key: "direction", Value: direction},
+	}
+
+	if r.config.DryRun {
+		script := mg.UpSQL
+		if !up {
+			script = mg.DownSQL
+		}
+		if (up && mg.Up == nil) || (!up && mg.Down == nil) {
+			fields = append(fields, log.Field{Key: "sql", Value: script})
+		}
+		logger.Info("database migration pending (dry run)", fields...)
+		return nil
+	}
+
+	start := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := fn(ctx, tx); err != nil {
+			return err
+		}
+		if up {
+			return tx.Table(r.config.Table).Create(&schemaMigration{
+				Version:   mg.Version,
+				Name:      mg.Name,
+				AppliedAt: time.Now().UTC(),
+			}).Error
+		}
+		return tx.Table(r.config.Table).Where("version = ?", mg.Version).Delete(&schemaMigration{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s %s failed: %w", mg.Version, mg.Name, direction, err)
+	}
+
+	logger.Info("database migration applied", append(fields, log.Field{Key: "duration", Value: time.Since(start)})...)
+	return nil
+}
+
+// ensureTable creates the migration table if it does not exist. In dry-run mode
+// the table is left untouched.
+func (r *MigrationRunner) ensureTable(db *gorm.DB) error {
+	if r.config.DryRun {
+		return nil
+	}
+	if err := db.Table(r.config.Table).AutoMigrate(&schemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create migration table %s: %w", r.config.Table, err)
+	}
+	return nil
+}
+
+// applied returns the rows of the migration table by version. A missing table
+// means no migration has been applied.
+func (r *MigrationRunner) applied(db *gorm.DB) (map[int64]schemaMigration, error) {
+	applied := make(map[int64]schemaMigration)
+	if !db.Migrator().HasTable(r.config.Table) {
+		return applied, nil
+	}
+
+	var rows []schemaMigration
+	if err := db.Table(r.config.Table).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read migration table %s: %w", r.config.Table, err)
+	}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// migrationSession is a single connection to the migrated database
+type migrationSession struct {
+	// db runs statements on conn only
+	db   *gorm.DB
+	conn *sql.Conn
+	// unlock releases the migration lock, if taken
+	unlock func() error
+}
+
+// release releases the migration lock and returns the connection to its pool
+func (s *migrationSession) release() error {
+	var err error
+	if s.unlock != nil {
+		if err = s.unlock(); err != nil {
+			err = fmt.Errorf("failed to release migration lock: %w", err)
+		}
+	}
+	return errors.Join(err, s.conn.Close())
+}
+
+// migrationNode returns the node the migrations run on: the first source of
+// the default group, where writes go, or the primary if there are no sources
+func (r *MigrationRunner) migrationNode() *node {
+	for _, n := range r.manager.nodes {
+		if n.role == RoleSource && n.cluster == "" {
+			return n
+		}
+	}
+	return r.manager.nodes[0]
+}
+
+// open opens a session on a connection of the migration node without taking
+// the lock. Statements of the session bypass dbresolver, so the lock and the
+// migrations always share the connection.
+func (r *MigrationRunner) open(ctx context.Context) (*migrationSession, error) {
+	conn, err := r.migrationNode().sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration connection: %w", err)
+	}
+
+	base := r.manager.db
+	dialector, err := createConnDialector(DatabaseType(base.Dialector.Name()), conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger:         base.Logger,
+		NamingStrategy: base.NamingStrategy,
+		NowFunc:        base.NowFunc,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open migration connection: %w", err)
+	}
+	return &migrationSession{db: db.WithContext(ctx), conn: conn}, nil
+}
+
+// begin opens a session and takes the migration lock on its connection
+func (r *MigrationRunner) begin(ctx context.Context) (*migrationSession, error) {
+	session, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if session.unlock, err = r.lock(ctx, session.conn); err != nil {
+		session.conn.Close()
+		return nil, err
+	}
+	return session, nil
+}
+
+// find returns the migration with the given version
+func (r *MigrationRunner) find(version int64) (Migration, bool) {
+	i := sort.Search(len(r.migrations), func(i int) bool { return r.migrations[i].Version >= version })
+	if i < len(r.migrations) && r.migrations[i].Version == version {
+		return r.migrations[i], true
+	}
+	return Migration{}, false
+}
+
+// splitSQLStatements splits a SQL script on semicolons, ignoring those inside
+// quotes, comments and PostgreSQL dollar-quoted strings. Empty statements are dropped.
+func splitSQLStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == ';':
+			flush()
+			continue
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			current.WriteString(script[i : i+end])
+			i += end - 1
+			continue
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i - 4
+			}
+			current.WriteString(script[i : i+end+4])
+			i += end + 3
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(script) {
+				if script[end] == '\\' && c == '\'' {
+					end += 2
+					continue
+				}
+				if script[end] == c {
+					break
+				}
+				end++
+			}
+			end = min(end, len(script)-1)
+			current.WriteString(script[i : end+1])
+			i = end
+			continue
+		case c == '$':
+			if tag := dollarQuoteTag(script[i:]); tag != "" {
+				end := strings.Index(script[i+len(tag):], tag)
+				if end < 0 {
+					end = len(script) - i - 2*len(tag)
+				}
+				current.WriteString(script[i : i+2*len(tag)+end])
+				i += 2*len(tag) + end - 1
+				continue
+			}
+		}
+		current.WriteByte(c)
+	}
+	flush()
+	return statements
+}
+
+// dollarQuoteTag returns the PostgreSQL dollar-quote tag such as $$ or $body$
+// at the start of s, or an empty string
+func dollarQuoteTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == '$' {
+			return s[:i+1]
+		}
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// migrationLockPollInterval is how often a SQLite lock file is retried
+const migrationLockPollInterval = 50 * time.Millisecond
+
+// lock takes the migration lock of the runner's table on conn, waiting up to
+// LockTimeout. It returns a function releasing the lock; conn must stay open
+// until it is called.
+func (r *MigrationRunner) lock(ctx context.Context, conn *sql.Conn) (func() error, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.LockTimeout)
+	defer cancel()
+
+	var unlock func() error
+	var err error
+	switch dbType := DatabaseType(r.manager.db.Dialector.Name()); dbType {
+	case PostgreSQL:
+		unlock, err = r.lockPostgreSQL(ctx, conn)
+	case MySQL:
+		unlock, err = r.lockMySQL(ctx, conn)
+	case SQLite:
+		unlock, err = r.lockSQLite(ctx, conn)
+	default:
+		return nil, fmt.Errorf("migration lock is not supported for database type: %s", dbType)
+	}
+	if err != nil && ctx.Err() != nil && !errors.Is(err, ErrMigrationLocked) {
+		return nil, fmt.Errorf("%w: %w", ErrMigrationLocked, err)
+	}
+	return unlock, err
+}
+
+// lockPostgreSQL takes a session-level advisory lock keyed by the table name
+func (r *MigrationRunner) lockPostgreSQL(ctx context.Context, conn *sql.Conn) (func() error, error) {
+	key := migrationLockKey(r.config.Table)
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		return nil, err
+	}
+	return func() error {
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+		return err
+	}, nil
+}
+
+// lockMySQL takes a named lock scoped to the current database and table name
+func (r *MigrationRunner) lockMySQL(ctx context.Context, conn *sql.Conn) (func() error, error) {
+	name := "migrate:" + r.config.Table
+	timeout := r.config.LockTimeout.Seconds()
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline).Seconds()
+	}
+
+	var acquired *int
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(CONCAT(DATABASE(), ':', ?), ?)", name, int(timeout)).Scan(&acquired); err != nil {
+		return nil, err
+	}
+	if acquired == nil || *acquired != 1 {
+		return nil, ErrMigrationLocked
+	}
+	return func() error {
+		_, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(CONCAT(DATABASE(), ':', ?))", name)
+		return err
+	}, nil
+}
+
+// lockSQLite locks a file next to the database file. In-memory databases are
+// private to the process and are not locked.
+func (r *MigrationRunner) lockSQLite(ctx context.Context, conn *sql.Conn) (func() error, error) {
+	var file string
+	if err := conn.QueryRowContext(ctx, "SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file); err != nil {
+		return nil, err
+	}
+	if file == "" {
+		return func() error { return nil }, nil
+	}
+
+	path := file + "." + r.config.Table + ".lock"
+	for {
+		unlock, err := lockFile(path)
+		if err == nil {
+			return unlock, nil
+		}
+		if !errors.Is(err, errFileLocked) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrMigrationLocked
+		case <-time.After(migrationLockPollInterval):
+		}
+	}
+}
+
+// migrationLockKey derives a PostgreSQL advisory lock key from the table name
+func migrationLockKey(table string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("migrate:" + table))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,27 @@
+//go:build !unix
+
+package db
+
+import (
+	"errors"
+	"os"
+)
+
+// errFileLocked is returned by lockFile when another process holds the lock
+var errFileLocked = errors.New("file is locked")
+
+// lockFile creates path exclusively without blocking. The lock is released by
+// the returned function, which removes the file; a process that exits without
+// releasing it leaves a stale lock file that must be removed by hand.
+func lockFile(path string) (func() error, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0o644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, errFileLocked
+		}
+		return nil, err
+	}
+	return func() error {
+		return errors.Join(f.Close(), os.Remove(path))
+	}, nil
+}
@@ -0,0 +1,241 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var testMigrationFS = fstest.MapFS{
+	"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);\nCREATE INDEX idx_users_name ON users (name);")},
+	"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	"migrations/0002_seed_users.up.sql":     {Data: []byte("-- seed; with a semicolon in a comment\nINSERT INTO users (name) VALUES ('a;b');")},
+	"migrations/README.md":                  {Data: []byte("ignored")},
+}
+
+func newMigrationManager(t *testing.T) *GORMManager {
+	t.Helper()
+	manager, err := NewGORMManagerFromConfig(ManagerConfig{Primary: sqliteConfig(t, t.TempDir(), "migrate")})
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+	return manager
+}
+
+func testMigrations(t *testing.T) []Migration {
+	t.Helper()
+	migrations, err := SQLMigrations(testMigrationFS, "migrations")
+	require.NoError(t, err)
+	return append(migrations, Migration{
+		Version: 3,
+		Name:    "add_email",
+		Up: func(ctx context.Context, tx *gorm.DB) error {
+			return tx.Exec("ALTER TABLE users ADD COLUMN email TEXT").Error
+		},
+		Down: func(ctx context.Context, tx *gorm.DB) error {
+			return tx.Exec("ALTER TABLE users DROP COLUMN email").Error
+		},
+	})
+}
+
+func appliedVersions(t *testing.T, r *MigrationRunner) []int64 {
+	t.Helper()
+	statuses, err := r.Status(context.Background())
+	require.NoError(t, err)
+	var versions []int64
+	for _, s := range statuses {
+		if s.Applied {
+			versions = append(versions, s.Version)
+		}
+	}
+	return versions
+}
+
+func TestSQLMigrations(t *testing.T) {
+	migrations, err := SQLMigrations(testMigrationFS, "migrations")
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "create_users", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE users;", migrations[0].DownSQL)
+	assert.Equal(t, "seed_users", migrations[1].Name)
+
+	_, err = SQLMigrations(fstest.MapFS{"0001_x.down.sql": {Data: []byte("SELECT 1")}}, ".")
+	assert.ErrorContains(t, err, "has no up file")
+}
+
+func TestMigrationRunner(t *testing.T) {
+	manager := newMigrationManager(t)
+	ctx := context.Background()
+	runner, err := NewMigrationRunner(manager, MigrationConfig{}, testMigrations(t)...)
+	require.NoError(t, err)
+
+	applied, err := runner.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 3)
+	assert.Equal(t, []int64{1, 2, 3}, appliedVersions(t, runner))
+
+	var name string
+	require.NoError(t, manager.DB().Raw("SELECT name FROM users").Scan(&name).Error)
+	assert.Equal(t, "a;b", name)
+
+	applied, err = runner.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied, "applied migrations are skipped")
+
+	reverted, err := runner.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, int64(3), reverted[0].Version)
+	assert.Equal(t, []int64{1, 2}, appliedVersions(t, runner))
+
+	reverted, err = runner.Down(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, reverted)
+
+	_, err = runner.Down(ctx, -1)
+	assert.ErrorContains(t, err, "invalid number of migrations to revert")
+
+	_, err = runner.Down(ctx, 5)
+	assert.ErrorContains(t, err, "migration 2_seed_users has no down migration")
+}
+
+func TestMigrationRunner_Sources(t *testing.T) {
+	manager := newSQLiteReadWriteManager(t, 2, 1)
+	runner, err := NewMigrationRunner(manager, MigrationConfig{}, testMigrations(t)...)
+	require.NoError(t, err)
+
+	session, err := runner.begin(context.Background())
+	require.NoError(t, err)
+	var file string
+	require.NoError(t, session.conn.QueryRowContext(context.Background(), "SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file))
+	assert.Equal(t, manager.config.Sources[0].Database, file, "the lock is taken on the first source")
+	require.NoError(t, session.release())
+
+	_, err = runner.Up(context.Background())
+	require.NoError(t, err)
+
+	tables := func(n *node) []string {
+		var names []string
+		rows, err := n.sqlDB.Query("SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name")
+		require.NoError(t, err)
+		defer rows.Close()
+		for rows.Next() {
+			var name string
+			require.NoError(t, rows.Scan(&name))
+			names = append(names, name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"schema_migrations", "users"}, tables(manager.nodes[1]), "migrations run on the first source")
+	assert.Empty(t, tables(manager.nodes[0]))
+	assert.Empty(t, tables(manager.nodes[2]))
+}
+
+func TestMigrationRunner_Status(t *testing.T) {
+	manager := newMigrationManager(t)
+	ctx := context.Background()
+	migrations := testMigrations(t)
+
+	runner, err := NewMigrationRunner(manager, MigrationConfig{}, migrations...)
+	require.NoError(t, err)
+	_, err = runner.Up(ctx)
+	require.NoError(t, err)
+
+	// A runner that no longer knows migration 3 and has a new migration 4
+	runner, err = NewMigrationRunner(manager, MigrationConfig{}, migrations[0], migrations[1], Migration{Version: 4, Name: "next", UpSQL: "SELECT 1"})
+	require.NoError(t, err)
+	statuses, err := runner.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 4)
+
+	assert.True(t, statuses[0].Applied)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Equal(t, MigrationStatus{Version: 3, Name: "add_email", Applied: true, AppliedAt: statuses[2].AppliedAt, Missing: true}, statuses[2])
+	assert.Equal(t, MigrationStatus{Version: 4, Name: "next"}, statuses[3])
+}
+
+func TestMigrationRunner_DryRun(t *testing.T) {
+	manager := newMigrationManager(t)
+	logger := &recordingLogger{}
+	manager.config.Logger = logger
+
+	runner, err := NewMigrationRunner(manager, MigrationConfig{DryRun: true}, testMigrations(t)...)
+	require.NoError(t, err)
+
+	pending, err := runner.Up(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, pending, 3)
+	assert.Equal(t, []string{
+		"database migration pending (dry run)",
+		"database migration pending (dry run)",
+		"database migration pending (dry run)",
+	}, logger.Messages())
+	assert.False(t, manager.DB().Migrator().HasTable("users"))
+	assert.False(t, manager.DB().Migrator().HasTable(defaultMigrationTable))
+}
+
+func TestMigrationRunner_Failure(t *testing.T) {
+	manager := newMigrationManager(t)
+	migrations := append(testMigrations(t), Migration{Version: 4, Name: "broken", UpSQL: "INSERT INTO users (name) VALUES ('c'); SELEC 1"})
+
+	runner, err := NewMigrationRunner(manager, MigrationConfig{}, migrations...)
+	require.NoError(t, err)
+	applied, err := runner.Up(context.Background())
+	assert.ErrorContains(t, err, "migration 4_broken up failed")
+	assert.Len(t, applied, 3)
+	assert.Equal(t, []int64{1, 2, 3}, appliedVersions(t, runner))
+
+	var count int64
+	require.NoError(t, manager.DB().Table("users").Count(&count).Error)
+	assert.Equal(t, int64(1), count, "failed migration is rolled back")
+}
+
+func TestNewMigrationRunner_Invalid(t *testing.T) {
+	manager := newMigrationManager(t)
+
+	_, err := NewMigrationRunner(manager, MigrationConfig{}, Migration{Version: 1, UpSQL: "SELECT 1"}, Migration{Version: 1, UpSQL: "SELECT 2"})
+	assert.ErrorContains(t, err, "duplicate migration version 1")
+
+	_, err = NewMigrationRunner(manager, MigrationConfig{}, Migration{Version: 0, UpSQL: "SELECT 1"})
+	assert.ErrorContains(t, err, "invalid version")
+
+	_, err = NewMigrationRunner(manager, MigrationConfig{}, Migration{Version: 1, Name: "empty"})
+	assert.ErrorContains(t, err, "has no up migration")
+}
+
+func TestMigrationRunner_Lock(t *testing.T) {
+	manager := newMigrationManager(t)
+	runner, err := NewMigrationRunner(manager, MigrationConfig{LockTimeout: 100 * time.Millisecond}, testMigrations(t)...)
+	require.NoError(t, err)
+
+	session, err := runner.begin(context.Background())
+	require.NoError(t, err)
+
+	_, err = runner.Up(context.Background())
+	assert.ErrorIs(t, err, ErrMigrationLocked)
+
+	require.NoError(t, session.release())
+	_, err = runner.Up(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestSplitSQLStatements(t *testing.T) {
+	script := `
+CREATE TABLE a (v TEXT DEFAULT 'x;y');
+-- comment; here
+/* block; comment */ INSERT INTO a VALUES ('it''s; fine');
+CREATE FUNCTION f() RETURNS void AS $body$ BEGIN PERFORM 1; END; $body$ LANGUAGE plpgsql;
+;
+`
+	assert.Equal(t, []string{
+		"CREATE TABLE a (v TEXT DEFAULT 'x;y')",
+		"-- comment; here\n/* block; comment */ INSERT INTO a VALUES ('it''s; fine')",
+		"CREATE FUNCTION f() RETURNS void AS $body$ BEGIN PERFORM 1; END; $body$ LANGUAGE plpgsql",
+	}, splitSQLStatements(script))
+	assert.Empty(t, splitSQLStatements(" ; \n"))
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+)
+
+// NodeRole is the role of a database connection within a GORMManager
+type NodeRole string
+
+const (
+	// RolePrimary is the primary connection
+	RolePrimary NodeRole = "primary"
+	// RoleSource is a write connection from ManagerConfig.Sources
+	RoleSource NodeRole = "source"
+	// RoleReplica is a read-only connection from ManagerConfig.Replicas
+	RoleReplica NodeRole = "replica"
+)
+
+// node is a database connection opened by a GORMManager
+type node struct {
+	role  NodeRole
+	index int
+	// cluster is the ManagerConfig.Clusters name, empty for the default group
+	cluster string
+	config  DBConfig
+	sqlDB   *sql.DB
+
+	// evicted is set while a replica fails health checks
+	evicted atomic.Bool
+	// lagging is set while a replica is behind by more than the allowed lag
+	lagging atomic.Bool
+	// failures and successes count consecutive health check results;
+	// they are only accessed by the replica monitor
+	failures  int
+	successes int
+}
+
+// available reports whether the node can receive reads
+func (n *node) available() bool {
+	return !n.evicted.Load() && !n.lagging.Load()
+}
+
+// name returns a stable identifier such as "primary", "source-0" or "replica-1".
+// Nodes of a cluster are prefixed with its name, as in "audit/replica-0".
+func (n *node) name() string {
+	if n.role == RolePrimary {
+		return string(RolePrimary)
+	}
+	if n.cluster != "" {
+		return fmt.Sprintf("%s/%s-%d", n.cluster, n.role, n.index)
+	}
+	return fmt.Sprintf("%s-%d", n.role, n.index)
+}
@@ -10,6 +10,9 @@ import "strings"
 //
 // Return:
 // - a string representing the generated SQL ORDER BY clause.
+//
+// Deprecated: the fields are written into the SQL unchecked, which allows SQL
+// injection when they come from user input. Use ParseSort and SortScope instead.
 func RawOrderBySQL[ListOrders ~[]string](o ListOrders, separator string) string {
 	if len(o) == 0 {
 		return ""
@@ -0,0 +1,23 @@
+package db
+
+import (
+	"github.com/ducminhgd/gao/pagination"
+	"gorm.io/gorm"
+)
+
+// PagingScope returns a GORM scope applying the limit and offset of a page,
+// for use with db.Scopes alongside FilterScope and SortScope. The limit and
+// offset come from PagingOptions.LimitOffset, so a zero page or page size
+// takes its default (page 1 of 20 records).
+//
+// Parameters:
+// - opts: the paging options.
+//
+// Returns:
+// - func(*gorm.DB) *gorm.DB: the scope.
+func PagingScope(opts pagination.PagingOptions) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		offset, limit := opts.LimitOffset()
+		return db.Offset(offset).Limit(limit)
+	}
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// LoadBalancePolicy selects how a connection is chosen among several sources or replicas
+type LoadBalancePolicy string
+
+const (
+	// PolicyRandom picks a connection at random
+	PolicyRandom LoadBalancePolicy = "random"
+	// PolicyRoundRobin cycles through the connections in order
+	PolicyRoundRobin LoadBalancePolicy = "round_robin"
+	// PolicyWeighted picks a connection at random in proportion to DBConfig.Weight
+	PolicyWeighted LoadBalancePolicy = "weighted"
+	// PolicyLeastConnections picks the connection with the fewest connections in use
+	PolicyLeastConnections LoadBalancePolicy = "least_connections"
+)
+
+// newPolicy creates the dbresolver.Policy for a LoadBalancePolicy.
+// nodes maps connection pools to their nodes for weights and pool statistics.
+func newPolicy(policy LoadBalancePolicy, nodes map[gorm.ConnPool]*node) (dbresolver.Policy, error) {
+	switch policy {
+	case "", PolicyRandom:
+		return dbresolver.RandomPolicy{}, nil
+	case PolicyRoundRobin:
+		return dbresolver.StrictRoundRobinPolicy(), nil
+	case PolicyWeighted:
+		return weightedPolicy{nodes: nodes}, nil
+	case PolicyLeastConnections:
+		return &leastConnectionsPolicy{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported load balance policy: %s", policy)
+	}
+}
+
+// weightedPolicy picks a connection at random in proportion to its weight
+type weightedPolicy struct {
+	nodes map[gorm.ConnPool]*node
+}
+
+// weight returns the weight of a connection pool, defaulting to 1
+func (p weightedPolicy) weight(connPool gorm.ConnPool) int {
+	if n, ok := p.nodes[connPool]; ok && n.config.Weight > 0 {
+		return n.config.Weight
+	}
+	return 1
+}
+
+// Resolve implements dbresolver.Policy
+func (p weightedPolicy) Resolve(connPools []gorm.ConnPool) gorm.ConnPool {
+	total := 0
+	for _, c := range connPools {
+		total += p.weight(c)
+	}
+
+	pick := rand.Intn(total)
+	for _, c := range connPools {
+		pick -= p.weight(c)
+		if pick < 0 {
+			return c
+		}
+	}
+	return connPools[len(connPools)-1]
+}
+
+// leastConnectionsPolicy picks the connection with the fewest connections in
+// use according to sql.DBStats. Ties are broken by rotating the starting point.
+type leastConnectionsPolicy struct {
+	next atomic.Uint64
+}
+
+// Resolve implements dbresolver.Policy
+func (p *leastConnectionsPolicy) Resolve(connPools []gorm.ConnPool) gorm.ConnPool {
+	start := int(p.next.Add(1) % uint64(len(connPools)))
+
+	best := connPools[start]
+	bestInUse := inUse(best)
+	for i := 1; i < len(connPools); i++ {
+		c := connPools[(start+i)%len(connPools)]
+		if n := inUse(c); n < bestInUse {
+			best, bestInUse = c, n
+		}
+	}
+	return best
+}
+
+// inUse returns the number of connections in use, or 0 if the pool does not expose statistics
+func inUse(connPool gorm.ConnPool) int {
+	if sqlDB, ok := connPool.(*sql.DB); ok {
+		return sqlDB.Stats().InUse
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPolicyManager creates a manager with SQLite replicas using the given policy and weights
+func newPolicyManager(t *testing.T, policy LoadBalancePolicy, weights ...int) *GORMManager {
+	t.Helper()
+	dir := t.TempDir()
+
+	config := ManagerConfig{
+		Primary: sqliteConfig(t, dir, "primary"),
+		Policy:  policy,
+	}
+	for i, w := range weights {
+		replica := sqliteConfig(t, dir, fmt.Sprintf("replica%d", i))
+		replica.Weight = w
+		config.Replicas = append(config.Replicas, replica)
+	}
+
+	manager, err := NewGORMManagerFromConfig(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+	seedNodes(t, manager)
+	return manager
+}
+
+// readDistribution counts how many of n reads were routed to each node
+func readDistribution(t *testing.T, manager *GORMManager, n int) map[string]int {
+	t.Helper()
+	counts := map[string]int{}
+	for i := 0; i < n; i++ {
+		counts[readNode(t, manager)]++
+	}
+	return counts
+}
+
+func TestPolicy_Random(t *testing.T) {
+	manager := newPolicyManager(t, PolicyRandom, 1, 1, 1)
+
+	counts := readDistribution(t, manager, 300)
+
+	assert.Len(t, counts, 3)
+	for name, c := range counts {
+		assert.Greater(t, c, 50, name)
+	}
+}
+
+func TestPolicy_RoundRobin(t *testing.T) {
+	manager := newPolicyManager(t, PolicyRoundRobin, 1, 1, 1)
+
+	counts := readDistribution(t, manager, 300)
+
+	assert.Equal(t, map[string]int{"replica-0": 100, "replica-1": 100, "replica-2": 100}, counts)
+}
+
+func TestPolicy_Weighted(t *testing.T) {
+	manager := newPolicyManager(t, PolicyWeighted, 3, 1, 0)
+
+	counts := readDistribution(t, manager, 1000)
+
+	// Expected shares are 3/5, 1/5 and 1/5 (a zero weight defaults to 1)
+	assert.InDelta(t, 600, counts["replica-0"], 100)
+	assert.InDelta(t, 200, counts["replica-1"], 75)
+	assert.InDelta(t, 200, counts["replica-2"], 75)
+}
+
+func TestPolicy_LeastConnections(t *testing.T) {
+	manager := newPolicyManager(t, PolicyLeastConnections, 1, 1)
+
+	// Hold a connection on replica-0 so it has more connections in use
+	conn, err := manager.nodes[1].sqlDB.Conn(context.Background())
+	require.NoError(t, err)
+
+	counts := readDistribution(t, manager, 50)
+	assert.Equal(t, map[string]int{"replica-1": 50}, counts)
+
+	require.NoError(t, conn.Close())
+	counts = readDistribution(t, manager, 50)
+	assert.Len(t, counts, 2)
+}
+
+func TestPolicy_Unsupported(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewGORMManagerFromConfig(ManagerConfig{
+		Primary:  sqliteConfig(t, dir, "primary"),
+		Replicas: []DBConfig{sqliteConfig(t, dir, "replica0")},
+		Policy:   "fastest",
+	})
+	assert.Error(t, err)
+}
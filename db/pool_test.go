@@ -0,0 +1,49 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerNodePoolConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	poolConfig := func(maxOpen int) PoolConfig {
+		return PoolConfig{
+			ConnMaxIdleTime: time.Minute,
+			ConnMaxLifetime: time.Hour,
+			MaxIdleConns:    1,
+			MaxOpenConns:    maxOpen,
+		}
+	}
+	primary := sqliteConfig(t, dir, "primary")
+	primary.PoolConfig = poolConfig(2)
+	source := sqliteConfig(t, dir, "source0")
+	source.PoolConfig = poolConfig(3)
+	replica0 := sqliteConfig(t, dir, "replica0")
+	replica0.PoolConfig = poolConfig(4)
+	replica1 := sqliteConfig(t, dir, "replica1")
+	replica1.PoolConfig = poolConfig(5)
+
+	manager, err := NewGORMManagerFromConfig(ManagerConfig{
+		Primary:  primary,
+		Sources:  []DBConfig{source},
+		Replicas: []DBConfig{replica0, replica1},
+	})
+	require.NoError(t, err)
+	defer manager.Close()
+
+	expected := map[string]int{
+		"primary":   2,
+		"source-0":  3,
+		"replica-0": 4,
+		"replica-1": 5,
+	}
+	require.Len(t, manager.nodes, len(expected))
+	for _, n := range manager.nodes {
+		assert.Equal(t, expected[n.name()], n.sqlDB.Stats().MaxOpenConnections, n.name())
+	}
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// replicaRouter is the dbresolver.Policy installed by NewGORMManagerFromConfig.
+// It skips replicas that are out of rotation and falls back to the write
+// connections when no replica is available.
+type replicaRouter struct {
+	nodes    map[gorm.ConnPool]*node
+	replicas []gorm.ConnPool
+	fallback []gorm.ConnPool
+	policy   dbresolver.Policy
+}
+
+// newReplicaRouter creates a router over one group of sources and replicas,
+// balancing with the given policy. Reads fall back to the sources when no
+// replica is available.
+func newReplicaRouter(sources, replicas []*node, policy LoadBalancePolicy) (*replicaRouter, error) {
+	r := &replicaRouter{
+		nodes: make(map[gorm.ConnPool]*node, len(sources)+len(replicas)),
+	}
+	for _, n := range sources {
+		r.nodes[n.sqlDB] = n
+		r.fallback = append(r.fallback, n.sqlDB)
+	}
+	for _, n := range replicas {
+		r.nodes[n.sqlDB] = n
+		r.replicas = append(r.replicas, n.sqlDB)
+	}
+
+	var err error
+	if r.policy, err = newPolicy(policy, r.nodes); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Resolve implements dbresolver.Policy
+func (r *replicaRouter) Resolve(connPools []gorm.ConnPool) gorm.ConnPool {
+	available := make([]gorm.ConnPool, 0, len(connPools))
+	for _, p := range connPools {
+		if n, ok := r.nodes[p]; !ok || n.available() {
+			available = append(available, p)
+		}
+	}
+
+	if len(available) == 0 {
+		available = r.fallback
+	}
+	if len(available) == 1 {
+		return available[0]
+	}
+	return r.policy.Resolve(available)
+}
+
+// reroute moves a read off a replica that is out of rotation. dbresolver does
+// not consult the policy when there is a single replica, so the resolved
+// connection is checked here as well. It reports whether the connection
+// belongs to this router.
+func (r *replicaRouter) reroute(db *gorm.DB, connPool gorm.ConnPool) bool {
+	n, ok := r.nodes[connPool]
+	if !ok {
+		return false
+	}
+	if n.role == RoleReplica && !n.available() {
+		db.Statement.ConnPool = r.Resolve(r.replicas)
+	}
+	return true
+}
+
+// routers dispatches the reroute callback to the router owning the resolved
+// connection, one router per resolver group
+type routers []*replicaRouter
+
+func (rs routers) reroute(db *gorm.DB) {
+	connPool := db.Statement.ConnPool
+	if prepared, ok := connPool.(*gorm.PreparedStmtDB); ok {
+		connPool = prepared.ConnPool
+	}
+	for _, r := range rs {
+		if r.reroute(db, connPool) {
+			return
+		}
+	}
+}
+
+// register installs the reroute callback on db, after dbresolver's own callbacks
+func (rs routers) register(db *gorm.DB) error {
+	if err := db.Callback().Query().After("gorm:db_resolver").Before("gorm:query").Register("gao:replica_router", rs.reroute); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().After("gorm:db_resolver").Before("gorm:row").Register("gao:replica_router", rs.reroute); err != nil {
+		return err
+	}
+	return db.Callback().Raw().After("gorm:db_resolver").Before("gorm:raw").Register("gao:replica_router", rs.reroute)
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gao/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingLogger is a log.Logger that records messages
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) record(msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, msg)
+}
+
+func (l *recordingLogger) Messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.messages...)
+}
+
+func (l *recordingLogger) Debug(msg string, fields ...log.Field)      { l.record(msg) }
+func (l *recordingLogger) Info(msg string, fields ...log.Field)       { l.record(msg) }
+func (l *recordingLogger) Warn(msg string, fields ...log.Field)       { l.record(msg) }
+func (l *recordingLogger) Error(msg string, fields ...log.Field)      { l.record(msg) }
+func (l *recordingLogger) Fatal(msg string, fields ...log.Field)      { l.record(msg) }
+func (l *recordingLogger) WithFields(fields ...log.Field) log.Logger  { return l }
+func (l *recordingLogger) WithContext(ctx context.Context) log.Logger { return l }
+
+// seedNodes creates a "nodes" table on every node holding the node's name
+func seedNodes(t *testing.T, manager *GORMManager) {
+	t.Helper()
+	for _, n := range manager.nodes {
+		_, err := n.sqlDB.Exec("CREATE TABLE nodes (name TEXT)")
+		require.NoError(t, err)
+		_, err = n.sqlDB.Exec("INSERT INTO nodes (name) VALUES (?)", n.name())
+		require.NoError(t, err)
+	}
+}
+
+// readNode returns the name of the node a read was routed to
+func readNode(t *testing.T, manager *GORMManager) string {
+	t.Helper()
+	var name string
+	require.NoError(t, manager.DB().Table("nodes").Select("name").Limit(1).Scan(&name).Error)
+	return name
+}
+
+func TestReplicaRouter_SingleReplicaEviction(t *testing.T) {
+	logger := &recordingLogger{}
+	manager := newSQLiteReadWriteManager(t, 0, 1)
+	manager.config.Logger = logger
+	seedNodes(t, manager)
+
+	replica := manager.nodes[1]
+	assert.Equal(t, "replica-0", readNode(t, manager))
+
+	replica.evicted.Store(true)
+	assert.Equal(t, "primary", readNode(t, manager))
+
+	manager.checkReplicas(context.Background())
+	assert.True(t, replica.available())
+	assert.Equal(t, "replica-0", readNode(t, manager))
+	assert.Equal(t, []string{"database replica re-admitted"}, logger.Messages())
+}
+
+func TestReplicaRouter_FallbackToSources(t *testing.T) {
+	manager := newSQLiteReadWriteManager(t, 1, 2)
+	seedNodes(t, manager)
+
+	for _, n := range manager.replicaNodes() {
+		n.evicted.Store(true)
+	}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "source-0", readNode(t, manager))
+	}
+}
+
+func TestReplicaRouter_SkipsEvictedReplica(t *testing.T) {
+	manager := newSQLiteReadWriteManager(t, 0, 2)
+	seedNodes(t, manager)
+
+	manager.nodes[1].evicted.Store(true)
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, "replica-1", readNode(t, manager))
+	}
+}
+
+func TestCheckReplicas(t *testing.T) {
+	logger := &recordingLogger{}
+	manager := newSQLiteReadWriteManager(t, 0, 2)
+	manager.config.Logger = logger
+	manager.config.HealthCheck.FailureThreshold = 2
+
+	replicas := manager.replicaNodes()
+	require.NoError(t, replicas[0].sqlDB.Close())
+
+	manager.checkReplicas(context.Background())
+	assert.True(t, replicas[0].available(), "evicted before reaching the failure threshold")
+
+	manager.checkReplicas(context.Background())
+	assert.False(t, replicas[0].available())
+	assert.True(t, replicas[1].available())
+	assert.Equal(t, []string{"database replica evicted"}, logger.Messages())
+
+	require.NoError(t, replicas[1].sqlDB.Close())
+	manager.checkReplicas(context.Background())
+	manager.checkReplicas(context.Background())
+	assert.False(t, replicas[1].available())
+	assert.Equal(t, []string{
+		"database replica evicted",
+		"database replica evicted",
+		"all database replicas unavailable, reads fall back to sources",
+	}, logger.Messages())
+}
+
+func TestReplicaMonitor(t *testing.T) {
+	dir := t.TempDir()
+	manager, err := NewGORMManagerFromConfig(ManagerConfig{
+		Primary:     sqliteConfig(t, dir, "primary"),
+		Replicas:    []DBConfig{sqliteConfig(t, dir, "replica0")},
+		HealthCheck: HealthCheckConfig{Interval: 5 * time.Millisecond},
+		Logger:      log.NewNop(),
+	})
+	require.NoError(t, err)
+
+	replica := manager.nodes[1]
+	require.NoError(t, replica.sqlDB.Close())
+
+	assert.Eventually(t, func() bool { return !replica.available() }, 2*time.Second, 5*time.Millisecond)
+	require.NoError(t, manager.Close())
+	assert.Nil(t, manager.monitorStop)
+}
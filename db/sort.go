@@ -0,0 +1,167 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidSort is returned by ParseSort for malformed or unknown sort fields
+var ErrInvalidSort = errors.New("db: invalid sort")
+
+// NullsOrder places NULL values before or after the other values
+type NullsOrder string
+
+const (
+	// NullsDefault leaves NULL placement to the database
+	NullsDefault NullsOrder = ""
+	// NullsFirst places NULL values first
+	NullsFirst NullsOrder = "nulls_first"
+	// NullsLast places NULL values last
+	NullsLast NullsOrder = "nulls_last"
+)
+
+// SortField is one ordering term of a SortSpec
+type SortField struct {
+	// Field is the API field name
+	Field string
+	// Column is the database column the field maps to
+	Column string
+	// Desc sorts in descending order
+	Desc bool
+	// Nulls places NULL values first or last
+	Nulls NullsOrder
+}
+
+// SortSpec is a validated list of ordering terms, applied with SortScope
+type SortSpec []SortField
+
+// ParseSort parses a sort parameter such as "name:desc,created_at" into a
+// SortSpec. Each term is FIELD[:asc|:desc][:nulls_first|:nulls_last] and FIELD
+// must be a key of allowed, which maps API field names to database columns.
+// Only columns from allowed reach the SQL, so s may come from a query string.
+//
+// Parameters:
+// - s: the sort parameter (empty returns an empty spec).
+// - allowed: the sortable API fields and their columns, e.g. {"created": "users.created_at"}.
+//
+// Returns:
+// - SortSpec: the parsed sort.
+// - error: an error wrapping ErrInvalidSort if a term is malformed or its field is not allowed.
+func ParseSort(s string, allowed map[string]string) (SortSpec, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	terms := strings.Split(s, ",")
+	spec := make(SortSpec, 0, len(terms))
+	seen := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		parts := strings.Split(strings.TrimSpace(term), ":")
+		field := parts[0]
+		column, ok := allowed[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidSort, field)
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("%w: duplicate field %q", ErrInvalidSort, field)
+		}
+		seen[field] = true
+
+		sf := SortField{Field: field, Column: column}
+		var hasDirection bool
+		for _, option := range parts[1:] {
+			switch strings.ToLower(option) {
+			case "asc", "desc":
+				if hasDirection || sf.Nulls != NullsDefault {
+					return nil, fmt.Errorf("%w: invalid term %q", ErrInvalidSort, term)
+				}
+				hasDirection = true
+				sf.Desc = strings.EqualFold(option, "desc")
+			case string(NullsFirst), string(NullsLast):
+				if sf.Nulls != NullsDefault {
+					return nil, fmt.Errorf("%w: invalid term %q", ErrInvalidSort, term)
+				}
+				sf.Nulls = NullsOrder(strings.ToLower(option))
+			default:
+				return nil, fmt.Errorf("%w: invalid option %q for field %q", ErrInvalidSort, option, field)
+			}
+		}
+		spec = append(spec, sf)
+	}
+	return spec, nil
+}
+
+// String formats the spec in the syntax accepted by ParseSort
+func (s SortSpec) String() string {
+	terms := make([]string, len(s))
+	for i, sf := range s {
+		term := sf.Field
+		if sf.Desc {
+			term += ":desc"
+		}
+		if sf.Nulls != NullsDefault {
+			term += ":" + string(sf.Nulls)
+		}
+		terms[i] = term
+	}
+	return strings.Join(terms, ",")
+}
+
+// SortScope returns a GORM scope ordering by spec, for use with db.Scopes.
+// Columns are quoted by the dialect. NULLS FIRST/LAST is emulated with an
+// IS NULL term on MySQL and SQL Server, which do not support it.
+//
+// Parameters:
+// - spec: the sort, typically from ParseSort.
+//
+// Returns:
+// - func(*gorm.DB) *gorm.DB: the scope.
+func SortScope(spec SortSpec) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(spec) == 0 {
+			return db
+		}
+
+		columns := make([]clause.OrderByColumn, 0, len(spec))
+		for _, sf := range spec {
+			column := clause.Column{Name: sf.Column}
+			if sf.Nulls == NullsDefault {
+				columns = append(columns, clause.OrderByColumn{Column: column, Desc: sf.Desc})
+				continue
+			}
+
+			quoted := db.Statement.Quote(column)
+			switch db.Dialector.Name() {
+			case string(MySQL):
+				columns = append(columns,
+					clause.OrderByColumn{Column: clause.Column{Name: quoted + " IS NULL", Raw: true}, Desc: sf.Nulls == NullsFirst},
+					clause.OrderByColumn{Column: column, Desc: sf.Desc},
+				)
+				continue
+			case string(SQLServer):
+				columns = append(columns,
+					clause.OrderByColumn{Column: clause.Column{Name: "CASE WHEN " + quoted + " IS NULL THEN 1 ELSE 0 END", Raw: true}, Desc: sf.Nulls == NullsFirst},
+					clause.OrderByColumn{Column: column, Desc: sf.Desc},
+				)
+				continue
+			}
+
+			expr := quoted
+			if sf.Desc {
+				expr += " DESC"
+			}
+			if sf.Nulls == NullsFirst {
+				expr += " NULLS FIRST"
+			} else {
+				expr += " NULLS LAST"
+			}
+			columns = append(columns, clause.OrderByColumn{Column: clause.Column{Name: expr, Raw: true}})
+		}
+		return db.Clauses(clause.OrderBy{Columns: columns})
+	}
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var testSortFields = map[string]string{
+	"name":    "name",
+	"created": "users.created_at",
+	"score":   "score",
+}
+
+func TestParseSort(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		expect SortSpec
+		err    string
+	}{
+		{name: "empty", input: " "},
+		{
+			name:   "default ascending",
+			input:  "name",
+			expect: SortSpec{{Field: "name", Column: "name"}},
+		},
+		{
+			name:  "multiple fields",
+			input: "name:desc, created:ASC",
+			expect: SortSpec{
+				{Field: "name", Column: "name", Desc: true},
+				{Field: "created", Column: "users.created_at"},
+			},
+		},
+		{
+			name:   "nulls placement",
+			input:  "score:desc:nulls_last",
+			expect: SortSpec{{Field: "score", Column: "score", Desc: true, Nulls: NullsLast}},
+		},
+		{
+			name:   "nulls placement without direction",
+			input:  "score:nulls_first",
+			expect: SortSpec{{Field: "score", Column: "score", Nulls: NullsFirst}},
+		},
+		{name: "unknown field", input: "password", err: `unknown field "password"`},
+		{name: "injection", input: "name; DROP TABLE users", err: "unknown field"},
+		{name: "invalid direction", input: "name:sideways", err: `invalid option "sideways"`},
+		{name: "duplicate field", input: "name,name:desc", err: `duplicate field "name"`},
+		{name: "two directions", input: "name:asc:desc", err: "invalid term"},
+		{name: "empty term", input: "name,", err: `unknown field ""`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := ParseSort(tc.input, testSortFields)
+			if tc.err != "" {
+				assert.ErrorIs(t, err, ErrInvalidSort)
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, spec)
+		})
+	}
+}
+
+func TestSortSpec_String(t *testing.T) {
+	spec, err := ParseSort("name:desc,created,score:asc:nulls_last", testSortFields)
+	require.NoError(t, err)
+	assert.Equal(t, "name:desc,created,score:nulls_last", spec.String())
+}
+
+func sortSQL(t *testing.T, db *gorm.DB, sort string) string {
+	t.Helper()
+	spec, err := ParseSort(sort, testSortFields)
+	require.NoError(t, err)
+	return db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Table("users").Scopes(SortScope(spec)).Find(&[]map[string]any{})
+	})
+}
+
+func TestSortScope(t *testing.T) {
+	manager := newTxManager(t)
+	db := manager.DB()
+
+	assert.Equal(t, "SELECT * FROM `users` ORDER BY `name` DESC,`users`.`created_at`", sortSQL(t, db, "name:desc,created"))
+	assert.Equal(t, "SELECT * FROM `users` ORDER BY `score` DESC NULLS LAST", sortSQL(t, db, "score:desc:nulls_last"))
+	assert.Equal(t, "SELECT * FROM `users`", sortSQL(t, db, ""))
+}
+
+func TestSortScope_Query(t *testing.T) {
+	manager := newTxManager(t)
+	db := manager.DB()
+	require.NoError(t, db.Exec("CREATE TABLE users (name TEXT, score INTEGER)").Error)
+	require.NoError(t, db.Exec("INSERT INTO users VALUES ('a', 2), ('b', NULL), ('c', 1)").Error)
+
+	for sort, expect := range map[string][]string{
+		"score:nulls_first":      {"b", "c", "a"},
+		"score:desc:nulls_last":  {"a", "c", "b"},
+		"score:desc:nulls_first": {"b", "a", "c"},
+		"name:desc":              {"c", "b", "a"},
+	} {
+		spec, err := ParseSort(sort, testSortFields)
+		require.NoError(t, err)
+		var names []string
+		require.NoError(t, db.Table("users").Scopes(SortScope(spec)).Pluck("name", &names).Error)
+		assert.Equal(t, expect, names, sort)
+	}
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ducminhgd/gao/internal/promtext"
+	"github.com/ducminhgd/gao/log"
+)
+
+// NodeStats is the connection pool statistics of a single database connection
+type NodeStats struct {
+	// Name identifies the connection, e.g. "primary", "source-0" or "replica-1"
+	Name string `json:"name"`
+	// Role is the role of the connection
+	Role NodeRole `json:"role"`
+	// Stats is the statistics of the connection pool
+	Stats sql.DBStats `json:"stats"`
+}
+
+// Stats returns the connection pool statistics of the primary, every source
+// and every replica, primary first. Connections added with AddSourceDialector
+// or AddReplicaDialector are not included.
+//
+// Returns:
+// - []NodeStats: the statistics of every connection.
+func (m *GORMManager) Stats() []NodeStats {
+	stats := make([]NodeStats, len(m.nodes))
+	for i, n := range m.nodes {
+		stats[i] = NodeStats{
+			Name:  n.name(),
+			Role:  n.role,
+			Stats: n.sqlDB.Stats(),
+		}
+	}
+	return stats
+}
+
+// startStatsReporter starts logging the pool statistics every
+// ManagerConfig.StatsInterval if configured
+func (m *GORMManager) startStatsReporter() {
+	if m.config.StatsInterval <= 0 {
+		return
+	}
+	m.runEvery(m.config.StatsInterval, m.logStats)
+}
+
+// logStats logs the pool statistics of every connection at info level
+func (m *GORMManager) logStats(context.Context) {
+	logger := m.logger()
+	for _, ns := range m.Stats() {
+		logger.Info("database pool stats",
+			log.Field{Key: "node", Value: ns.Name},
+			log.Field{Key: "role", Value: string(ns.Role)},
+			log.Field{Key: "max_open", Value: ns.Stats.MaxOpenConnections},
+			log.Field{Key: "open", Value: ns.Stats.OpenConnections},
+			log.Field{Key: "in_use", Value: ns.Stats.InUse},
+			log.Field{Key: "idle", Value: ns.Stats.Idle},
+			log.Field{Key: "wait_count", Value: ns.Stats.WaitCount},
+			log.Field{Key: "wait_duration", Value: ns.Stats.WaitDuration},
+			log.Field{Key: "max_idle_closed", Value: ns.Stats.MaxIdleClosed},
+			log.Field{Key: "max_idle_time_closed", Value: ns.Stats.MaxIdleTimeClosed},
+			log.Field{Key: "max_lifetime_closed", Value: ns.Stats.MaxLifetimeClosed},
+		)
+	}
+}
+
+// statsMetric is a pool statistic exposed by WritePrometheusStats
+type statsMetric struct {
+	name  string
+	kind  string
+	help  string
+	value func(s sql.DBStats) float64
+}
+
+var statsMetrics = []statsMetric{
+	{"db_max_open_connections", "gauge", "Maximum number of open connections to the database.",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+	{"db_open_connections", "gauge", "Number of established connections, both in use and idle.",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+	{"db_in_use_connections", "gauge", "Number of connections currently in use.",
+		func(s sql.DBStats) float64 { return float64(s.InUse) }},
+	{"db_idle_connections", "gauge", "Number of idle connections.",
+		func(s sql.DBStats) float64 { return float64(s.Idle) }},
+	{"db_wait_count_total", "counter", "Total number of connections waited for.",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+	{"db_wait_duration_seconds_total", "counter", "Total time blocked waiting for a new connection.",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+	{"db_max_idle_closed_total", "counter", "Total number of connections closed due to SetMaxIdleConns.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+	{"db_max_idle_time_closed_total", "counter", "Total number of connections closed due to SetConnMaxIdleTime.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }},
+	{"db_max_lifetime_closed_total", "counter", "Total number of connections closed due to SetConnMaxLifetime.",
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+}
+
+// WritePrometheusStats writes the pool statistics of every connection in the
+// Prometheus text exposition format, labelled with the connection name and role,
+// e.g. db_open_connections{node="replica-0",role="replica"} 3.
+//
+// Parameters:
+// - w: the writer.
+//
+// Returns:
+// - error: an error if writing fails.
+func (m *GORMManager) WritePrometheusStats(w io.Writer) error {
+	stats := m.Stats()
+
+	bw := bufio.NewWriter(w)
+	for _, metric := range statsMetrics {
+		promtext.WriteHeader(bw, metric.name, metric.kind, metric.help)
+		for _, ns := range stats {
+			fmt.Fprintf(bw, "%s{node=\"%s\",role=\"%s\"} %g\n",
+				metric.name, promtext.EscapeLabelValue(ns.Name), promtext.EscapeLabelValue(string(ns.Role)), metric.value(ns.Stats))
+		}
+	}
+	return bw.Flush()
+}
+
+// StatsHandler returns an http.Handler rendering WritePrometheusStats for a
+// Prometheus scrape.
+func (m *GORMManager) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", promtext.ContentType)
+		_ = m.WritePrometheusStats(w)
+	})
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	manager := newSQLiteReadWriteManager(t, 1, 1)
+
+	conn, err := manager.nodes[0].sqlDB.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	stats := manager.Stats()
+	require.Len(t, stats, 3)
+	assert.Equal(t, "primary", stats[0].Name)
+	assert.Equal(t, RolePrimary, stats[0].Role)
+	assert.Equal(t, "source-0", stats[1].Name)
+	assert.Equal(t, "replica-0", stats[2].Name)
+	assert.Equal(t, RoleReplica, stats[2].Role)
+	assert.Equal(t, 1, stats[0].Stats.InUse)
+	assert.Equal(t, DefaultPoolConfig().MaxOpenConns, stats[0].Stats.MaxOpenConnections)
+}
+
+func TestStatsHandler(t *testing.T) {
+	manager := newSQLiteReadWriteManager(t, 0, 1)
+
+	rec := httptest.NewRecorder()
+	manager.StatsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE db_open_connections gauge",
+		"# TYPE db_wait_duration_seconds_total counter",
+		`db_max_open_connections{node="primary",role="primary"} 10`,
+		`db_max_open_connections{node="replica-0",role="replica"} 10`,
+		`db_max_lifetime_closed_total{node="replica-0",role="replica"} 0`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+}
+
+func TestStatsReporter(t *testing.T) {
+	logger := &recordingLogger{}
+	config := ManagerConfig{
+		Primary:       sqliteConfig(t, t.TempDir(), "primary"),
+		StatsInterval: 5 * time.Millisecond,
+		Logger:        logger,
+	}
+	manager, err := NewGORMManagerFromConfig(config)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return len(logger.Messages()) > 0
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, "database pool stats", logger.Messages()[0])
+
+	require.NoError(t, manager.Close())
+	assert.Nil(t, manager.monitorStop)
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// stickyKey is the context key holding the read-your-writes state
+type stickyKey struct{}
+
+// stickyState records the time of the last write made with a context
+type stickyState struct {
+	lastWrite atomic.Int64
+}
+
+// WithStickyPrimary returns a context that tracks writes for read-your-writes
+// consistency. When ManagerConfig.StickyPrimaryWindow is set, reads made with
+// this context within the window after a write are sent to the sources instead
+// of the replicas. Typically called once per request, e.g. in an HTTP middleware.
+func WithStickyPrimary(ctx context.Context) context.Context {
+	if stickyFromContext(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, stickyKey{}, &stickyState{})
+}
+
+// stickyFromContext returns the read-your-writes state, or nil if ctx does not track writes
+func stickyFromContext(ctx context.Context) *stickyState {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(stickyKey{}).(*stickyState)
+	return s
+}
+
+// stickyRouter implements the sticky primary window with GORM callbacks
+type stickyRouter struct {
+	window time.Duration
+}
+
+// stickToSource re-resolves reads to the sources within the window after a write
+func (r stickyRouter) stickToSource(db *gorm.DB) {
+	s := stickyFromContext(db.Statement.Context)
+	if s == nil {
+		return
+	}
+	last := s.lastWrite.Load()
+	if last == 0 || time.Since(time.Unix(0, last)) > r.window {
+		return
+	}
+	dbresolver.Write.ModifyStatement(db.Statement)
+}
+
+// recordWrite records a successful write on the statement's context
+func (r stickyRouter) recordWrite(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	if s := stickyFromContext(db.Statement.Context); s != nil {
+		s.lastWrite.Store(time.Now().UnixNano())
+	}
+}
+
+// recordRawWrite records raw statements that are not reads
+func (r stickyRouter) recordRawWrite(db *gorm.DB) {
+	if !isReadSQL(db.Statement.SQL.String()) {
+		r.recordWrite(db)
+	}
+}
+
+// register installs the callbacks on db. Reads are re-resolved after dbresolver's
+// callback, which must run first.
+func (r stickyRouter) register(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Query().After("gorm:db_resolver").Before("gorm:query").Register("gao:sticky_primary", r.stickToSource); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:db_resolver").Before("gorm:row").Register("gao:sticky_primary", r.stickToSource); err != nil {
+		return err
+	}
+	if err := cb.Raw().After("gorm:db_resolver").Before("gorm:raw").Register("gao:sticky_primary", r.stickToSource); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("gao:sticky_primary", r.recordWrite); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("gao:sticky_primary", r.recordWrite); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("gao:sticky_primary", r.recordWrite); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("gao:sticky_primary_write", r.recordRawWrite)
+}
+
+// isReadSQL reports whether a raw SQL statement is a read, mirroring dbresolver
+func isReadSQL(rawSQL string) bool {
+	rawSQL = strings.TrimSpace(rawSQL)
+	return len(rawSQL) > 10 && strings.EqualFold(rawSQL[:6], "select") &&
+		!strings.EqualFold(rawSQL[len(rawSQL)-10:], "for update")
+}
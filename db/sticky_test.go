@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStickyManager creates a manager with one SQLite replica and a sticky primary window
+func newStickyManager(t *testing.T, window time.Duration) *GORMManager {
+	t.Helper()
+	dir := t.TempDir()
+	manager, err := NewGORMManagerFromConfig(ManagerConfig{
+		Primary:             sqliteConfig(t, dir, "primary"),
+		Replicas:            []DBConfig{sqliteConfig(t, dir, "replica0")},
+		StickyPrimaryWindow: window,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+	seedNodes(t, manager)
+	return manager
+}
+
+// readNodeContext returns the node a read made with ctx was routed to
+func readNodeContext(t *testing.T, manager *GORMManager, ctx context.Context) string {
+	t.Helper()
+	var name string
+	require.NoError(t, manager.DB().WithContext(ctx).Table("nodes").Select("name").Limit(1).Scan(&name).Error)
+	return name
+}
+
+func TestStickyPrimary(t *testing.T) {
+	manager := newStickyManager(t, 100*time.Millisecond)
+	ctx := WithStickyPrimary(context.Background())
+
+	assert.Equal(t, "replica-0", readNodeContext(t, manager, ctx))
+
+	require.NoError(t, manager.DB().WithContext(ctx).Table("nodes").Create(map[string]any{"name": "written"}).Error)
+	assert.Equal(t, "primary", readNodeContext(t, manager, ctx))
+
+	// Other contexts are not affected by the write
+	assert.Equal(t, "replica-0", readNodeContext(t, manager, context.Background()))
+	assert.Equal(t, "replica-0", readNodeContext(t, manager, WithStickyPrimary(context.Background())))
+
+	assert.Eventually(t, func() bool {
+		return readNodeContext(t, manager, ctx) == "replica-0"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestStickyPrimary_RawWrite(t *testing.T) {
+	manager := newStickyManager(t, time.Minute)
+	ctx := WithStickyPrimary(context.Background())
+
+	require.NoError(t, manager.DB().WithContext(ctx).Raw("SELECT name FROM nodes").Scan(&[]string{}).Error)
+	assert.Equal(t, "replica-0", readNodeContext(t, manager, ctx))
+
+	require.NoError(t, manager.DB().WithContext(ctx).Exec("UPDATE nodes SET name = name").Error)
+	assert.Equal(t, "primary", readNodeContext(t, manager, ctx))
+
+	var name string
+	require.NoError(t, manager.DB().WithContext(ctx).Raw("SELECT name FROM nodes LIMIT 1").Scan(&name).Error)
+	assert.Equal(t, "primary", name)
+}
+
+func TestStickyPrimary_Disabled(t *testing.T) {
+	manager := newStickyManager(t, 0)
+	ctx := WithStickyPrimary(context.Background())
+
+	require.NoError(t, manager.DB().WithContext(ctx).Table("nodes").Create(map[string]any{"name": "written"}).Error)
+	assert.Equal(t, "replica-0", readNodeContext(t, manager, ctx))
+}
+
+func TestWithStickyPrimary_Idempotent(t *testing.T) {
+	ctx := WithStickyPrimary(context.Background())
+	assert.Same(t, stickyFromContext(ctx), stickyFromContext(WithStickyPrimary(ctx)))
+	assert.Nil(t, stickyFromContext(context.Background()))
+}
+
+func TestIsReadSQL(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected bool
+	}{
+		{"SELECT * FROM users", true},
+		{"  select id from users", true},
+		{"SELECT * FROM users FOR UPDATE", false},
+		{"UPDATE users SET name = 'x'", false},
+		{"INSERT INTO users (id) VALUES (1)", false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.sql), func(t *testing.T) {
+			assert.Equal(t, tt.expected, isReadSQL(tt.sql))
+		})
+	}
+}
@@ -0,0 +1,474 @@
+package db
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ducminhgd/gao/log"
+	"gorm.io/gorm"
+)
+
+// defaultMaxTenants is used when TenantManagerConfig.MaxTenants is not set
+const defaultMaxTenants = 100
+
+var (
+	// ErrNoTenant is returned by DBForContext when the context carries no tenant ID
+	ErrNoTenant = errors.New("db: no tenant in context")
+	// ErrTenantManagerClosed is returned once the TenantManager has been closed
+	ErrTenantManagerClosed = errors.New("db: tenant manager is closed")
+)
+
+// TenantResolver returns the database configuration of a tenant
+type TenantResolver func(ctx context.Context, tenantID string) (DBConfig, error)
+
+// TenantManagerConfig configures a TenantManager
+type TenantManagerConfig struct {
+	// Resolver returns the database configuration of a tenant (required)
+	Resolver TenantResolver
+
+	// MaxTenants is the number of tenant connections kept open (default 100).
+	// Opening another tenant closes the least recently used one.
+	MaxTenants int
+
+	// IdleTimeout closes tenant connections unused for this long (zero disables it)
+	IdleTimeout time.Duration
+
+	// GormConfig contains GORM-specific configuration shared by every tenant
+	GormConfig *gorm.Config
+
+	// Logger receives tenant events such as eviction (default log.L())
+	Logger log.Logger
+}
+
+// tenantKey is the context key holding the tenant ID
+type tenantKey struct{}
+
+// ContextWithTenant returns a context carrying the tenant ID used by AcquireForContext and DBForContext
+func ContextWithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant ID stored by ContextWithTenant
+func TenantFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// tenant is a cached tenant connection. ready is closed once the connection
+// has been opened, after which manager or err is set.
+type tenant struct {
+	id       string
+	ready    chan struct{}
+	manager  *GORMManager
+	err      error
+	lastUsed time.Time
+
+	// refs counts the leases taken by Acquire, and removed is set once the
+	// tenant has left the cache; both are guarded by TenantManager.mu. A
+	// removed tenant is closed when its last lease is released.
+	refs    int
+	removed bool
+}
+
+// TenantManager opens one GORMManager per tenant on first use and caches it.
+// The least recently used tenants are closed when MaxTenants is exceeded, and
+// idle tenants are closed after IdleTimeout.
+//
+// Closing a tenant closes its connection pools, so handles returned by
+// Manager, DB and DBForContext fail with "sql: database is closed" once their
+// tenant is evicted, even between queries. Acquire leases a tenant instead:
+// leased tenants are not evicted for MaxTenants or IdleTimeout, and tenants
+// removed by Evict or Close while leased are closed when the last lease is
+// released.
+type TenantManager struct {
+	config TenantManagerConfig
+
+	mu      sync.Mutex
+	tenants map[string]*list.Element
+	lru     *list.List // front is the most recently used *tenant
+	closed  bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewTenantManager creates a TenantManager
+//
+// Parameters:
+// - config: the TenantManagerConfig for the manager.
+//
+// Returns:
+// - *TenantManager: the newly created TenantManager.
+// - error: an error if no resolver is configured.
+func NewTenantManager(config TenantManagerConfig) (*TenantManager, error) {
+	if config.Resolver == nil {
+		return nil, errors.New("db: tenant resolver is required")
+	}
+	if config.MaxTenants <= 0 {
+		config.MaxTenants = defaultMaxTenants
+	}
+
+	tm := &TenantManager{
+		config:  config,
+		tenants: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+	if config.IdleTimeout > 0 {
+		tm.stop = make(chan struct{})
+		tm.wg.Add(1)
+		go tm.closeIdleLoop()
+	}
+	return tm, nil
+}
+
+// Manager returns the GORMManager of a tenant, opening it on first use.
+//
+// The manager is not leased: MaxTenants or IdleTimeout eviction can close it
+// while it is still in use, so it is unsafe to hold across queries when
+// eviction is enabled. Use Acquire in request handlers.
+//
+// Parameters:
+// - ctx: the context passed to the resolver.
+// - tenantID: the tenant ID.
+//
+// Returns:
+// - *GORMManager: the tenant's manager, not leased.
+// - error: an error if the tenant cannot be resolved or opened.
+func (tm *TenantManager) Manager(ctx context.Context, tenantID string) (*GORMManager, error) {
+	t, err := tm.get(ctx, tenantID, false)
+	if err != nil {
+		return nil, err
+	}
+	return t.manager, nil
+}
+
+// Acquire leases a tenant and returns its *gorm.DB bound to ctx. The tenant
+// is not closed while leased, so the returned database stays usable until
+// release is called; typically a request handler acquires the tenant and
+// defers release.
+//
+// Parameters:
+// - ctx: the context for the resolver and the returned database.
+// - tenantID: the tenant ID.
+//
+// Returns:
+// - *gorm.DB: the tenant's database.
+// - func(): release ends the lease; calling it more than once has no effect.
+// - error: an error if the tenant cannot be resolved or opened.
+func (tm *TenantManager) Acquire(ctx context.Context, tenantID string) (*gorm.DB, func(), error) {
+	t, err := tm.get(ctx, tenantID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	var once sync.Once
+	release := func() { once.Do(func() { tm.release(t) }) }
+	return t.manager.DB().WithContext(ctx), release, nil
+}
+
+// AcquireForContext leases the tenant stored in ctx by ContextWithTenant, as Acquire
+//
+// Parameters:
+// - ctx: the context carrying the tenant ID.
+//
+// Returns:
+// - *gorm.DB: the tenant's database bound to ctx.
+// - func(): release ends the lease; calling it more than once has no effect.
+// - error: ErrNoTenant if ctx has no tenant ID, or an error if the tenant cannot be opened.
+func (tm *TenantManager) AcquireForContext(ctx context.Context) (*gorm.DB, func(), error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, nil, ErrNoTenant
+	}
+	return tm.Acquire(ctx, tenantID)
+}
+
+// get returns a tenant, opening it on first use, and leases it if lease is set
+func (tm *TenantManager) get(ctx context.Context, tenantID string, lease bool) (*tenant, error) {
+	tm.mu.Lock()
+	if tm.closed {
+		tm.mu.Unlock()
+		return nil, ErrTenantManagerClosed
+	}
+
+	if elem, ok := tm.tenants[tenantID]; ok {
+		t := elem.Value.(*tenant)
+		t.lastUsed = time.Now()
+		if lease {
+			t.refs++
+		}
+		tm.lru.MoveToFront(elem)
+		tm.mu.Unlock()
+
+		<-t.ready
+		if t.err != nil {
+			if lease {
+				tm.release(t)
+			}
+			return nil, t.err
+		}
+		return t, nil
+	}
+
+	t := &tenant{id: tenantID, ready: make(chan struct{}), lastUsed: time.Now()}
+	if lease {
+		t.refs = 1
+	}
+	tm.tenants[tenantID] = tm.lru.PushFront(t)
+	evicted := tm.evictLocked()
+	tm.mu.Unlock()
+
+	tm.closeTenants(evicted, "database tenant evicted")
+
+	t.manager, t.err = tm.open(ctx, tenantID)
+	if t.err != nil {
+		tm.mu.Lock()
+		if elem, ok := tm.tenants[tenantID]; ok && elem.Value == t {
+			tm.lru.Remove(elem)
+			delete(tm.tenants, tenantID)
+		}
+		tm.mu.Unlock()
+	}
+	close(t.ready)
+	if t.err != nil {
+		return nil, t.err
+	}
+	return t, nil
+}
+
+// release ends a lease taken by get, closing the tenant if it has been
+// removed from the cache and this was its last lease
+func (tm *TenantManager) release(t *tenant) {
+	tm.mu.Lock()
+	t.refs--
+	t.lastUsed = time.Now()
+	if elem, ok := tm.tenants[t.id]; ok && elem.Value == t {
+		// Keep the LRU list ordered by lastUsed for closeIdle
+		tm.lru.MoveToFront(elem)
+	}
+	closeNow := t.refs == 0 && t.removed
+	tm.mu.Unlock()
+
+	if closeNow {
+		tm.closeTenants([]*tenant{t}, "database tenant closed after its last lease")
+	}
+}
+
+// DB returns the *gorm.DB of a tenant bound to ctx.
+//
+// The database is not leased: MaxTenants or IdleTimeout eviction can close it
+// while a request is still using it, so it is unsafe when eviction is enabled.
+// Use Acquire in request handlers.
+//
+// Parameters:
+// - ctx: the context for the resolver and the returned database.
+// - tenantID: the tenant ID.
+//
+// Returns:
+// - *gorm.DB: the tenant's database, not leased.
+// - error: an error if the tenant cannot be resolved or opened.
+func (tm *TenantManager) DB(ctx context.Context, tenantID string) (*gorm.DB, error) {
+	manager, err := tm.Manager(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return manager.DB().WithContext(ctx), nil
+}
+
+// DBForContext returns the *gorm.DB of the tenant stored in ctx by ContextWithTenant.
+//
+// The database is not leased: MaxTenants or IdleTimeout eviction can close it
+// while a request is still using it, so it is unsafe when eviction is enabled.
+// Use AcquireForContext in request handlers.
+//
+// Parameters:
+// - ctx: the context carrying the tenant ID.
+//
+// Returns:
+// - *gorm.DB: the tenant's database bound to ctx, not leased.
+// - error: ErrNoTenant if ctx has no tenant ID, or an error if the tenant cannot be opened.
+func (tm *TenantManager) DBForContext(ctx context.Context) (*gorm.DB, error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	return tm.DB(ctx, tenantID)
+}
+
+// Evict closes a tenant's connections. The tenant is reopened on next use.
+// A leased tenant is closed when its last lease is released.
+func (tm *TenantManager) Evict(tenantID string) error {
+	tm.mu.Lock()
+	var t *tenant
+	closeNow := false
+	if elem, ok := tm.tenants[tenantID]; ok {
+		t, closeNow = tm.removeLocked(elem)
+	}
+	tm.mu.Unlock()
+
+	if !closeNow {
+		return nil
+	}
+	return tm.closeTenant(t)
+}
+
+// Len returns the number of cached tenants
+func (tm *TenantManager) Len() int {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	return tm.lru.Len()
+}
+
+// Close closes every tenant's connections; leased tenants are closed when
+// their last lease is released. Manager then returns ErrTenantManagerClosed.
+func (tm *TenantManager) Close() error {
+	tm.mu.Lock()
+	if tm.closed {
+		tm.mu.Unlock()
+		return nil
+	}
+	tm.closed = true
+	var tenants []*tenant
+	for elem := tm.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if t, closeNow := tm.removeLocked(elem); closeNow {
+			tenants = append(tenants, t)
+		}
+		elem = next
+	}
+	tm.mu.Unlock()
+
+	if tm.stop != nil {
+		close(tm.stop)
+		tm.wg.Wait()
+	}
+
+	var errs []error
+	for _, t := range tenants {
+		if err := tm.closeTenant(t); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// open resolves a tenant's configuration and opens its manager
+func (tm *TenantManager) open(ctx context.Context, tenantID string) (*GORMManager, error) {
+	config, err := tm.config.Resolver(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve tenant %s: %w", tenantID, err)
+	}
+	manager, err := NewGORMManagerFromConfig(ManagerConfig{
+		Primary:    config,
+		GormConfig: tm.config.GormConfig,
+		Logger:     tm.config.Logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tenant %s: %w", tenantID, err)
+	}
+	return manager, nil
+}
+
+// removeLocked removes a tenant from the cache and reports whether it can be
+// closed now, that is whether it is not leased. The caller must hold tm.mu.
+func (tm *TenantManager) removeLocked(elem *list.Element) (*tenant, bool) {
+	t := elem.Value.(*tenant)
+	tm.lru.Remove(elem)
+	delete(tm.tenants, t.id)
+	t.removed = true
+	return t, t.refs == 0
+}
+
+// evictLocked removes the least recently used tenants beyond MaxTenants.
+// Tenants still being opened or leased are skipped. The caller must hold tm.mu.
+func (tm *TenantManager) evictLocked() []*tenant {
+	var evicted []*tenant
+	for elem := tm.lru.Back(); elem != nil && tm.lru.Len() > tm.config.MaxTenants; {
+		prev := elem.Prev()
+		if t := elem.Value.(*tenant); opened(t) && t.refs == 0 {
+			t, _ = tm.removeLocked(elem)
+			evicted = append(evicted, t)
+		}
+		elem = prev
+	}
+	return evicted
+}
+
+// closeIdleLoop closes idle tenants until the manager is closed
+func (tm *TenantManager) closeIdleLoop() {
+	defer tm.wg.Done()
+
+	interval := tm.config.IdleTimeout / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-tm.stop:
+			return
+		case <-ticker.C:
+			tm.closeIdle(time.Now())
+		}
+	}
+}
+
+// closeIdle closes the tenants unused since before now - IdleTimeout.
+// Leased tenants are in use and are kept.
+func (tm *TenantManager) closeIdle(now time.Time) {
+	tm.mu.Lock()
+	var idle []*tenant
+	for elem := tm.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		t := elem.Value.(*tenant)
+		if now.Sub(t.lastUsed) < tm.config.IdleTimeout {
+			break
+		}
+		if opened(t) && t.refs == 0 {
+			t, _ = tm.removeLocked(elem)
+			idle = append(idle, t)
+		}
+		elem = prev
+	}
+	tm.mu.Unlock()
+
+	tm.closeTenants(idle, "database tenant closed after idle timeout")
+}
+
+// closeTenants closes tenants removed from the cache, logging each one
+func (tm *TenantManager) closeTenants(tenants []*tenant, msg string) {
+	logger := tm.logger()
+	for _, t := range tenants {
+		fields := []log.Field{{Key: "tenant", Value: t.id}}
+		if err := tm.closeTenant(t); err != nil {
+			fields = append(fields, log.Field{Key: "error", Value: err.Error()})
+		}
+		logger.Info(msg, fields...)
+	}
+}
+
+// closeTenant waits for a tenant to finish opening and closes its manager
+func (tm *TenantManager) closeTenant(t *tenant) error {
+	<-t.ready
+	if t.manager == nil {
+		return nil
+	}
+	return t.manager.Close()
+}
+
+// opened reports whether a tenant has finished opening
+func opened(t *tenant) bool {
+	select {
+	case <-t.ready:
+		return true
+	default:
+		return false
+	}
+}
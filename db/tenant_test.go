@@ -0,0 +1,192 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTenantManager(t *testing.T, config TenantManagerConfig) (*TenantManager, *atomic.Int32) {
+	t.Helper()
+	skipUnlessSupported(t, SQLite)
+	dir := t.TempDir()
+
+	var resolved atomic.Int32
+	config.Resolver = func(ctx context.Context, tenantID string) (DBConfig, error) {
+		resolved.Add(1)
+		if tenantID == "unknown" {
+			return DBConfig{}, errors.New("tenant not found")
+		}
+		return sqliteConfig(t, dir, tenantID), nil
+	}
+
+	tm, err := NewTenantManager(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { tm.Close() })
+	return tm, &resolved
+}
+
+func TestTenantManager_DBForContext(t *testing.T) {
+	tm, resolved := newTestTenantManager(t, TenantManagerConfig{})
+
+	for _, tenantID := range []string{"acme", "globex"} {
+		db, err := tm.DBForContext(ContextWithTenant(context.Background(), tenantID))
+		require.NoError(t, err)
+		require.NoError(t, db.Exec("CREATE TABLE tenant (name TEXT)").Error)
+		require.NoError(t, db.Exec("INSERT INTO tenant (name) VALUES (?)", tenantID).Error)
+	}
+
+	var name string
+	db, err := tm.DBForContext(ContextWithTenant(context.Background(), "acme"))
+	require.NoError(t, err)
+	require.NoError(t, db.Raw("SELECT name FROM tenant").Scan(&name).Error)
+	assert.Equal(t, "acme", name)
+	assert.Equal(t, int32(2), resolved.Load())
+	assert.Equal(t, 2, tm.Len())
+
+	_, err = tm.DBForContext(context.Background())
+	assert.ErrorIs(t, err, ErrNoTenant)
+}
+
+func TestTenantManager_ResolverError(t *testing.T) {
+	tm, resolved := newTestTenantManager(t, TenantManagerConfig{})
+
+	for i := 0; i < 2; i++ {
+		_, err := tm.DB(context.Background(), "unknown")
+		assert.ErrorContains(t, err, "tenant not found")
+	}
+	assert.Equal(t, int32(2), resolved.Load(), "failed tenants are not cached")
+	assert.Equal(t, 0, tm.Len())
+}
+
+func TestTenantManager_ConcurrentOpen(t *testing.T) {
+	tm, resolved := newTestTenantManager(t, TenantManagerConfig{})
+
+	var wg sync.WaitGroup
+	managers := make([]*GORMManager, 10)
+	for i := range managers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			managers[i], err = tm.Manager(context.Background(), "acme")
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), resolved.Load())
+	for _, m := range managers {
+		assert.Same(t, managers[0], m)
+	}
+}
+
+func TestTenantManager_LRUEviction(t *testing.T) {
+	tm, resolved := newTestTenantManager(t, TenantManagerConfig{MaxTenants: 2})
+	ctx := context.Background()
+
+	acme, err := tm.Manager(ctx, "acme")
+	require.NoError(t, err)
+	_, err = tm.Manager(ctx, "globex")
+	require.NoError(t, err)
+	_, err = tm.Manager(ctx, "acme")
+	require.NoError(t, err)
+	_, err = tm.Manager(ctx, "initech")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, tm.Len())
+	assert.True(t, acme.HealthCheck(ctx).Healthy, "most recently used tenant stays open")
+
+	// globex was the least recently used tenant and is reopened
+	_, err = tm.Manager(ctx, "globex")
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), resolved.Load())
+}
+
+func TestTenantManager_CloseIdle(t *testing.T) {
+	tm, _ := newTestTenantManager(t, TenantManagerConfig{IdleTimeout: time.Hour})
+	ctx := context.Background()
+
+	acme, err := tm.Manager(ctx, "acme")
+	require.NoError(t, err)
+	_, err = tm.Manager(ctx, "globex")
+	require.NoError(t, err)
+
+	tm.closeIdle(time.Now().Add(30 * time.Minute))
+	assert.Equal(t, 2, tm.Len())
+
+	tm.closeIdle(time.Now().Add(2 * time.Hour))
+	assert.Equal(t, 0, tm.Len())
+	assert.False(t, acme.HealthCheck(ctx).Healthy, "idle tenant is closed")
+}
+
+func TestTenantManager_Close(t *testing.T) {
+	tm, _ := newTestTenantManager(t, TenantManagerConfig{})
+	ctx := context.Background()
+
+	acme, err := tm.Manager(ctx, "acme")
+	require.NoError(t, err)
+	require.NoError(t, tm.Close())
+	require.NoError(t, tm.Close())
+
+	assert.False(t, acme.HealthCheck(ctx).Healthy)
+	_, err = tm.Manager(ctx, "acme")
+	assert.ErrorIs(t, err, ErrTenantManagerClosed)
+}
+
+func TestNewTenantManager_NoResolver(t *testing.T) {
+	_, err := NewTenantManager(TenantManagerConfig{})
+	assert.Error(t, err)
+}
+
+func TestTenantManager_Acquire(t *testing.T) {
+	tm, _ := newTestTenantManager(t, TenantManagerConfig{MaxTenants: 1, IdleTimeout: time.Hour})
+	ctx := context.Background()
+
+	db, release, err := tm.AcquireForContext(ContextWithTenant(ctx, "acme"))
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("CREATE TABLE tenant (name TEXT)").Error)
+
+	// A leased tenant is kept by LRU and idle eviction
+	_, err = tm.Manager(ctx, "globex")
+	require.NoError(t, err)
+	tm.closeIdle(time.Now().Add(2 * time.Hour))
+	require.NoError(t, db.Exec("INSERT INTO tenant (name) VALUES ('a')").Error)
+
+	// Evict defers the close of a leased tenant until the last release
+	_, release2, err := tm.Acquire(ctx, "acme")
+	require.NoError(t, err)
+	require.NoError(t, tm.Evict("acme"))
+	require.NoError(t, db.Exec("INSERT INTO tenant (name) VALUES ('b')").Error)
+
+	release()
+	release()
+	require.NoError(t, db.Exec("INSERT INTO tenant (name) VALUES ('c')").Error, "releasing twice ends one lease")
+	release2()
+	assert.ErrorContains(t, db.Exec("INSERT INTO tenant (name) VALUES ('d')").Error, "database is closed")
+
+	_, _, err = tm.AcquireForContext(ctx)
+	assert.ErrorIs(t, err, ErrNoTenant)
+	_, _, err = tm.Acquire(ctx, "unknown")
+	assert.ErrorContains(t, err, "tenant not found")
+}
+
+func TestTenantManager_CloseLeased(t *testing.T) {
+	tm, _ := newTestTenantManager(t, TenantManagerConfig{})
+	ctx := context.Background()
+
+	db, release, err := tm.Acquire(ctx, "acme")
+	require.NoError(t, err)
+	require.NoError(t, tm.Close())
+	assert.Equal(t, 0, tm.Len())
+	require.NoError(t, db.Exec("SELECT 1").Error)
+
+	release()
+	assert.Error(t, db.Exec("SELECT 1").Error)
+}
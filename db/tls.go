@@ -0,0 +1,127 @@
+package db
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSMode selects whether and how a connection is encrypted. The values follow
+// PostgreSQL's sslmode and are translated for MySQL.
+type TLSMode string
+
+const (
+	// TLSDefault leaves TLS to the driver defaults and Params
+	TLSDefault TLSMode = ""
+	// TLSDisable never uses TLS
+	TLSDisable TLSMode = "disable"
+	// TLSPrefer uses TLS when the server supports it, without verifying the certificate
+	TLSPrefer TLSMode = "prefer"
+	// TLSRequire always uses TLS, without verifying the certificate
+	TLSRequire TLSMode = "require"
+	// TLSVerifyCA always uses TLS and verifies the certificate chain against CAFile
+	// (or the system roots), but not the host name
+	TLSVerifyCA TLSMode = "verify-ca"
+	// TLSVerifyFull always uses TLS and verifies the certificate chain and host name
+	TLSVerifyFull TLSMode = "verify-full"
+)
+
+// TLSConfig configures the TLS connection to a database
+type TLSConfig struct {
+	// Mode selects whether and how the connection is encrypted
+	Mode TLSMode
+	// CAFile is a PEM file of the certificate authorities trusted to sign the server certificate
+	CAFile string
+	// CertFile is a PEM client certificate file for mutual TLS
+	CertFile string
+	// KeyFile is the PEM private key file of CertFile
+	KeyFile string
+}
+
+// validate checks the mode and that the certificate and key are set together
+func (t TLSConfig) validate() error {
+	switch t.Mode {
+	case TLSDefault, TLSDisable, TLSPrefer, TLSRequire, TLSVerifyCA, TLSVerifyFull:
+	default:
+		return fmt.Errorf("unsupported TLS mode: %s (supported: disable, prefer, require, verify-ca, verify-full)", t.Mode)
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return errors.New("TLS CertFile and KeyFile must be set together")
+	}
+	return nil
+}
+
+// hasFiles reports whether any certificate file is configured
+func (t TLSConfig) hasFiles() bool {
+	return t.CAFile != "" || t.CertFile != "" || t.KeyFile != ""
+}
+
+// clientConfig loads the certificate files into a tls.Config for host. It is
+// used by drivers that take a tls.Config rather than file names.
+func (t TLSConfig) clientConfig(host string) (*tls.Config, error) {
+	config := &tls.Config{ServerName: host}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA file: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in TLS CA file %s", t.CAFile)
+		}
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	switch t.Mode {
+	case TLSPrefer, TLSRequire:
+		config.InsecureSkipVerify = true
+	case TLSVerifyCA:
+		// Verify the chain without the host name, as PostgreSQL's verify-ca does
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = verifyChain(config.RootCAs)
+	}
+	return config, nil
+}
+
+// verifyChain returns a VerifyPeerCertificate callback checking the server
+// certificate chain against roots (nil uses the system roots)
+func verifyChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("server sent no certificate")
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs[i] = cert
+		}
+
+		opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(opts)
+		return err
+	}
+}
+
+// registrationName returns a stable name identifying the TLS settings for host,
+// for drivers that register tls.Configs by name
+func (t TLSConfig) registrationName(host string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s", t.Mode, t.CAFile, t.CertFile, t.KeyFile, host)))
+	return "gao-" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCA writes a self-signed CA certificate to a PEM file and returns its path
+func writeTestCA(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gao test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	return path
+}
+
+func TestTLSConfig_Validate(t *testing.T) {
+	assert.NoError(t, TLSConfig{}.validate())
+	assert.NoError(t, TLSConfig{Mode: TLSVerifyFull, CertFile: "client.pem", KeyFile: "client.key"}.validate())
+	assert.ErrorContains(t, TLSConfig{Mode: "always"}.validate(), "unsupported TLS mode")
+	assert.ErrorContains(t, TLSConfig{CertFile: "client.pem"}.validate(), "set together")
+}
+
+func TestTLSConfig_ClientConfig(t *testing.T) {
+	caFile := writeTestCA(t)
+
+	config, err := TLSConfig{Mode: TLSVerifyFull, CAFile: caFile}.clientConfig("db.internal")
+	require.NoError(t, err)
+	assert.Equal(t, "db.internal", config.ServerName)
+	assert.NotNil(t, config.RootCAs)
+	assert.False(t, config.InsecureSkipVerify)
+
+	config, err = TLSConfig{Mode: TLSVerifyCA, CAFile: caFile}.clientConfig("db.internal")
+	require.NoError(t, err)
+	assert.True(t, config.InsecureSkipVerify)
+	assert.NotNil(t, config.VerifyPeerCertificate)
+
+	_, err = TLSConfig{Mode: TLSVerifyFull, CAFile: filepath.Join(t.TempDir(), "missing.pem")}.clientConfig("db.internal")
+	assert.ErrorContains(t, err, "failed to read TLS CA file")
+}
+
+func TestBuildDSN_Errors(t *testing.T) {
+	_, err := (&DBConfig{Type: "oracle", Host: "localhost", Database: "app"}).BuildDSN()
+	assert.ErrorContains(t, err, "unsupported database type")
+
+	_, err = (&DBConfig{Type: PostgreSQL, Host: "localhost", Database: "app", TLS: TLSConfig{Mode: "always"}}).BuildDSN()
+	assert.ErrorContains(t, err, "unsupported TLS mode")
+
+	// Without the driver the error is ErrDriverNotCompiled rather than the DSN
+	if containsType(SupportedTypes(), PostgreSQL) {
+		_, err = NewGORMManagerFromConfig(ManagerConfig{Primary: DBConfig{Type: PostgreSQL, Host: "localhost", Database: "app", TLS: TLSConfig{KeyFile: "client.key"}}})
+		assert.ErrorContains(t, err, "failed to build DSN")
+	}
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+const (
+	// defaultTxMaxRetries is used when TxOptions.MaxRetries is not set
+	defaultTxMaxRetries = 3
+	// defaultTxRetryBackoff is used when TxOptions.RetryBackoff is not set
+	defaultTxRetryBackoff = 50 * time.Millisecond
+	// defaultTxMaxBackoff is used when TxOptions.MaxBackoff is not set
+	defaultTxMaxBackoff = 2 * time.Second
+)
+
+// TxOptions configures WithTransaction
+type TxOptions struct {
+	// Isolation is the transaction isolation level (default: the driver's default)
+	Isolation sql.IsolationLevel
+	// ReadOnly starts a read-only transaction, which runs on a replica when
+	// replicas are configured
+	ReadOnly bool
+	// MaxRetries is the number of retries after a retryable error (default 3, negative disables)
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled on each retry (default 50ms)
+	RetryBackoff time.Duration
+	// MaxBackoff caps the delay between retries (default 2s)
+	MaxBackoff time.Duration
+}
+
+// withDefaults returns the options with zero values replaced by defaults
+func (o TxOptions) withDefaults() TxOptions {
+	if o.MaxRetries == 0 {
+		o.MaxRetries = defaultTxMaxRetries
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = defaultTxRetryBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = defaultTxMaxBackoff
+	}
+	return o
+}
+
+// txKey is the context key holding the active transaction
+type txKey struct{}
+
+// ContextWithTx returns a context carrying tx, which FromContext and
+// WithTransaction pick up. WithTransaction already passes such a context to
+// its function; use this for transactions started by other means.
+func ContextWithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// FromContext returns the transaction carried by ctx, or the manager's
+// database when there is none, bound to ctx. Repository functions use it to
+// join the caller's transaction without taking a *gorm.DB parameter.
+//
+// Parameters:
+// - ctx: the context carrying the transaction.
+// - manager: the manager used outside a transaction.
+//
+// Returns:
+// - *gorm.DB: the transaction or database bound to ctx.
+func FromContext(ctx context.Context, manager *GORMManager) *gorm.DB {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.WithContext(ctx)
+	}
+	return manager.DB().WithContext(ctx)
+}
+
+// txFromContext returns the transaction carried by ctx, or nil
+func txFromContext(ctx context.Context) *gorm.DB {
+	if ctx == nil {
+		return nil
+	}
+	tx, _ := ctx.Value(txKey{}).(*gorm.DB)
+	return tx
+}
+
+// WithTransaction runs fn in a transaction, committing if fn returns nil and
+// rolling back otherwise. The context passed to fn carries the transaction.
+//
+// Serialization failures and deadlocks (PostgreSQL 40001/40P01, MySQL
+// 1213/1205, SQLite SQLITE_BUSY/SQLITE_LOCKED) roll back the transaction and
+// run fn again with exponential backoff, so fn must be safe to repeat.
+//
+// When ctx already carries a transaction, fn runs in a savepoint of it instead:
+// an error rolls back to the savepoint and is returned to the enclosing
+// transaction, which decides whether to retry. opts are ignored in that case.
+//
+// Parameters:
+// - ctx: the context for the transaction.
+// - opts: the transaction options (nil uses the defaults).
+// - fn: the function to run in the transaction.
+//
+// Returns:
+// - error: the error returned by fn, or an error from the database.
+func (m *GORMManager) WithTransaction(ctx context.Context, opts *TxOptions, fn func(ctx context.Context, tx *gorm.DB) error) error {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(ContextWithTx(ctx, tx), tx)
+		})
+	}
+
+	var o TxOptions
+	if opts != nil {
+		o = *opts
+	}
+	o = o.withDefaults()
+
+	db := m.db.WithContext(ctx)
+	if o.ReadOnly {
+		db = db.Clauses(dbresolver.Read)
+	} else {
+		db = db.Clauses(dbresolver.Write)
+	}
+	sqlOpts := &sql.TxOptions{Isolation: o.Isolation, ReadOnly: o.ReadOnly}
+
+	backoff := o.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return fn(ContextWithTx(ctx, tx), tx)
+		}, sqlOpts)
+		if err == nil || attempt >= o.MaxRetries || !isRetryableError(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > o.MaxBackoff {
+			backoff = o.MaxBackoff
+		}
+	}
+}
+
+// isRetryableError reports whether err is a serialization failure or deadlock
+// after which the transaction can be retried
+func isRetryableError(err error) bool {
+	return isPostgreSQLRetryable(err) || isMySQLRetryable(err) || isSQLiteRetryable(err) || isSQLServerRetryable(err)
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTxManager(t *testing.T) *GORMManager {
+	t.Helper()
+	config := sqliteConfig(t, t.TempDir(), "tx")
+	config.Params = "_busy_timeout=0"
+
+	manager, err := NewGORMManagerFromConfig(ManagerConfig{Primary: config})
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+	require.NoError(t, manager.DB().Exec("CREATE TABLE items (name TEXT)").Error)
+	return manager
+}
+
+// openSQLiteLocker opens a separate connection pool to the SQLite file at path
+// with whichever SQLite driver is compiled in
+func openSQLiteLocker(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	dialector, err := newSQLiteDialector(path)
+	require.NoError(t, err)
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	require.NoError(t, err)
+	locker, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { locker.Close() })
+	return locker
+}
+
+func itemNames(t *testing.T, manager *GORMManager) []string {
+	t.Helper()
+	var names []string
+	require.NoError(t, manager.DB().Table("items").Order("name").Pluck("name", &names).Error)
+	return names
+}
+
+func TestWithTransaction(t *testing.T) {
+	manager := newTxManager(t)
+	ctx := context.Background()
+
+	err := manager.WithTransaction(ctx, nil, func(ctx context.Context, tx *gorm.DB) error {
+		assert.Same(t, tx, txFromContext(ctx))
+		return tx.Exec("INSERT INTO items (name) VALUES ('a')").Error
+	})
+	require.NoError(t, err)
+
+	errFailed := errors.New("failed")
+	attempts := 0
+	err = manager.WithTransaction(ctx, nil, func(ctx context.Context, tx *gorm.DB) error {
+		attempts++
+		require.NoError(t, tx.Exec("INSERT INTO items (name) VALUES ('b')").Error)
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, 1, attempts, "non-retryable errors are not retried")
+	assert.Equal(t, []string{"a"}, itemNames(t, manager))
+}
+
+func TestWithTransaction_RetryBusy(t *testing.T) {
+	manager := newTxManager(t)
+	ctx := context.Background()
+
+	// Hold the write lock from another connection so the first attempt fails with SQLITE_BUSY
+	locker := openSQLiteLocker(t, manager.config.Primary.Database)
+	lockTx, err := locker.Begin()
+	require.NoError(t, err)
+	_, err = lockTx.Exec("INSERT INTO items (name) VALUES ('lock')")
+	require.NoError(t, err)
+
+	attempts := 0
+	err = manager.WithTransaction(ctx, &TxOptions{RetryBackoff: time.Millisecond}, func(ctx context.Context, tx *gorm.DB) error {
+		attempts++
+		err := tx.Exec("INSERT INTO items (name) VALUES ('a')").Error
+		if attempts == 1 {
+			assert.True(t, isRetryableError(err), "expected SQLITE_BUSY, got %v", err)
+			require.NoError(t, lockTx.Rollback())
+		}
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, []string{"a"}, itemNames(t, manager))
+}
+
+func TestWithTransaction_RetriesExhausted(t *testing.T) {
+	manager := newTxManager(t)
+
+	locker := openSQLiteLocker(t, manager.config.Primary.Database)
+	lockTx, err := locker.Begin()
+	require.NoError(t, err)
+	defer lockTx.Rollback()
+	_, err = lockTx.Exec("INSERT INTO items (name) VALUES ('lock')")
+	require.NoError(t, err)
+
+	attempts := 0
+	err = manager.WithTransaction(context.Background(), &TxOptions{MaxRetries: 2, RetryBackoff: time.Millisecond}, func(ctx context.Context, tx *gorm.DB) error {
+		attempts++
+		return tx.Exec("INSERT INTO items (name) VALUES ('a')").Error
+	})
+	assert.True(t, isRetryableError(err))
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	err = manager.WithTransaction(context.Background(), &TxOptions{MaxRetries: -1}, func(ctx context.Context, tx *gorm.DB) error {
+		attempts++
+		return tx.Exec("INSERT INTO items (name) VALUES ('a')").Error
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts, "negative MaxRetries disables retries")
+}
+
+func TestWithTransaction_Savepoint(t *testing.T) {
+	manager := newTxManager(t)
+	errNested := errors.New("nested failed")
+
+	err := manager.WithTransaction(context.Background(), nil, func(ctx context.Context, tx *gorm.DB) error {
+		if err := tx.Exec("INSERT INTO items (name) VALUES ('outer')").Error; err != nil {
+			return err
+		}
+
+		err := manager.WithTransaction(ctx, nil, func(ctx context.Context, tx *gorm.DB) error {
+			require.NoError(t, tx.Exec("INSERT INTO items (name) VALUES ('rolled back')").Error)
+			return errNested
+		})
+		assert.ErrorIs(t, err, errNested)
+
+		return manager.WithTransaction(ctx, nil, func(ctx context.Context, tx *gorm.DB) error {
+			return tx.Exec("INSERT INTO items (name) VALUES ('nested')").Error
+		})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nested", "outer"}, itemNames(t, manager))
+}
+
+func TestWithTransaction_Options(t *testing.T) {
+	manager := newTxManager(t)
+
+	err := manager.WithTransaction(context.Background(), &TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}, func(ctx context.Context, tx *gorm.DB) error {
+		var count int64
+		return tx.Table("items").Count(&count).Error
+	})
+	assert.NoError(t, err)
+}
+
+func TestFromContext(t *testing.T) {
+	manager := newTxManager(t)
+
+	// Repository functions taking only a context
+	insert := func(ctx context.Context, name string) error {
+		return FromContext(ctx, manager).Exec("INSERT INTO items (name) VALUES (?)", name).Error
+	}
+
+	require.NoError(t, insert(context.Background(), "outside"))
+
+	errFailed := errors.New("failed")
+	err := manager.WithTransaction(context.Background(), nil, func(ctx context.Context, tx *gorm.DB) error {
+		require.NoError(t, insert(ctx, "inside"))
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+
+	tx := manager.DB().Begin()
+	require.NoError(t, insert(ContextWithTx(context.Background(), tx), "manual"))
+	require.NoError(t, tx.Commit().Error)
+
+	assert.Equal(t, []string{"manual", "outside"}, itemNames(t, manager))
+}
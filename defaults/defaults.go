@@ -0,0 +1,161 @@
+// Package defaults fills struct fields from their `default` struct tags
+package defaults
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tag is the struct tag holding a field's default value
+const Tag = "default"
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Apply sets every zero-valued field of the struct v points to from its
+// `default` tag, e.g.
+//
+//	type PoolConfig struct {
+//		MaxOpenConns    int           `default:"10"`
+//		ConnMaxLifetime time.Duration `default:"60m"`
+//		Hosts           []string      `default:"a,b"`
+//	}
+//
+// Supported field types are strings, bools, integers, unsigned integers,
+// floats, time.Duration (time.ParseDuration syntax), types implementing
+// encoding.TextUnmarshaler, and slices of those with comma-separated
+// defaults. Nested structs, and structs in slices and maps, are filled
+// recursively whether or not they have a tag. Pointers are not followed.
+//
+// Because zero values are replaced, a default cannot be overridden by setting
+// a field to its zero value.
+//
+// Parameters:
+// - v: a non-nil pointer to a struct.
+//
+// Returns:
+// - error: an error if v is not a pointer to a struct, or a default cannot be parsed for its field.
+func Apply(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("defaults: expected a non-nil pointer to a struct, got %T", v)
+	}
+	return applyStruct(rv.Elem())
+}
+
+// applyStruct fills the fields of the addressable struct v
+func applyStruct(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, sf := v.Field(i), t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		if tag, ok := sf.Tag.Lookup(Tag); ok && field.IsZero() {
+			if err := set(field, tag); err != nil {
+				return fmt.Errorf("defaults: field %s.%s: invalid default %q: %w", t.Name(), sf.Name, tag, err)
+			}
+		}
+		if err := applyNested(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyNested fills the structs held by field: a struct, or the struct
+// elements of a slice, array or map
+func applyNested(field reflect.Value) error {
+	switch field.Kind() {
+	case reflect.Struct:
+		return applyStruct(field)
+	case reflect.Slice, reflect.Array:
+		if field.Type().Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		for i := 0; i < field.Len(); i++ {
+			if err := applyStruct(field.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if field.Type().Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		// Map values are not addressable, so each is filled on a copy
+		iter := field.MapRange()
+		for iter.Next() {
+			elem := reflect.New(field.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			if err := applyStruct(elem); err != nil {
+				return err
+			}
+			field.SetMapIndex(iter.Key(), elem)
+		}
+	}
+	return nil
+}
+
+// set parses s into the settable value v
+func set(v reflect.Value, s string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		parts := strings.Split(s, ",")
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := set(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return errors.New("unsupported type " + v.Type().String())
+	}
+	return nil
+}
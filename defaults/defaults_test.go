@@ -0,0 +1,77 @@
+package defaults
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pool struct {
+	MaxOpenConns    int           `default:"10"`
+	ConnMaxLifetime time.Duration `default:"60m"`
+}
+
+type server struct {
+	Name     string        `default:"gao"`
+	Enabled  bool          `default:"true"`
+	Port     uint16        `default:"8080"`
+	Ratio    float64       `default:"0.5"`
+	Hosts    []string      `default:"a, b"`
+	Ports    []int         `default:"80,443"`
+	IP       net.IP        `default:"127.0.0.1"`
+	Timeout  time.Duration `default:"2s"`
+	Pool     pool
+	Replicas []pool
+	Clusters map[string]pool
+	Backup   *pool
+	NoTag    int
+	internal int `default:"1"`
+}
+
+func TestApply(t *testing.T) {
+	s := server{
+		Name:     "custom",
+		Replicas: []pool{{}, {MaxOpenConns: 3}},
+		Clusters: map[string]pool{"audit": {ConnMaxLifetime: time.Minute}},
+		Backup:   &pool{},
+	}
+	require.NoError(t, Apply(&s))
+
+	assert.Equal(t, "custom", s.Name)
+	assert.True(t, s.Enabled)
+	assert.Equal(t, uint16(8080), s.Port)
+	assert.Equal(t, 0.5, s.Ratio)
+	assert.Equal(t, []string{"a", "b"}, s.Hosts)
+	assert.Equal(t, []int{80, 443}, s.Ports)
+	assert.Equal(t, "127.0.0.1", s.IP.String())
+	assert.Equal(t, 2*time.Second, s.Timeout)
+	assert.Equal(t, pool{MaxOpenConns: 10, ConnMaxLifetime: time.Hour}, s.Pool)
+	assert.Equal(t, []pool{{10, time.Hour}, {3, time.Hour}}, s.Replicas)
+	assert.Equal(t, pool{MaxOpenConns: 10, ConnMaxLifetime: time.Minute}, s.Clusters["audit"])
+	assert.Equal(t, pool{}, *s.Backup)
+	assert.Zero(t, s.NoTag)
+	assert.Zero(t, s.internal)
+}
+
+func TestApply_Errors(t *testing.T) {
+	assert.ErrorContains(t, Apply(pool{}), "expected a non-nil pointer to a struct")
+	assert.ErrorContains(t, Apply((*pool)(nil)), "expected a non-nil pointer to a struct")
+
+	var invalid struct {
+		Timeout time.Duration `default:"soon"`
+	}
+	assert.ErrorContains(t, Apply(&invalid), `field .Timeout: invalid default "soon"`)
+
+	var overflow struct {
+		Small int8 `default:"300"`
+	}
+	assert.ErrorContains(t, Apply(&overflow), "Small")
+
+	var unsupported struct {
+		Ch chan int `default:"1"`
+	}
+	assert.ErrorContains(t, Apply(&unsupported), "unsupported type chan int")
+}
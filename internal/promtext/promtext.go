@@ -0,0 +1,25 @@
+// Package promtext holds helpers for writing the Prometheus text exposition format
+package promtext
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ContentType is the Content-Type of a response in the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WriteHeader writes the HELP and TYPE lines of a metric
+func WriteHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+// labelValueReplacer escapes label values as required by the text format
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// EscapeLabelValue escapes a label value for use between double quotes
+func EscapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
@@ -0,0 +1,21 @@
+package promtext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteHeader(t *testing.T) {
+	var b strings.Builder
+	WriteHeader(&b, "requests_total", "counter", "Total number of requests.")
+	expected := "# HELP requests_total Total number of requests.\n# TYPE requests_total counter\n"
+	if b.String() != expected {
+		t.Errorf("expected %q, got %q", expected, b.String())
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	if got := EscapeLabelValue("a\\b\"c\nd"); got != `a\\b\"c\nd` {
+		t.Errorf("unexpected escaped value: %q", got)
+	}
+}
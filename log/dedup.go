@@ -0,0 +1,234 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RepeatedFieldKey is the field added by Dedup to the entry summarising the
+// repeats of an entry
+const RepeatedFieldKey = "repeated"
+
+// pendingEntry tracks the repeats of an entry until the end of its window
+type pendingEntry struct {
+	logger Logger
+	level  string
+	msg    string
+	fields []Field
+	count  int
+	timer  *time.Timer
+}
+
+// Dedup collapses identical entries (same level, message and field set)
+// emitted within a window. The first occurrence is written immediately and
+// starts the window; repeats are held back and, at the end of the window,
+// written once with a RepeatedFieldKey field holding the number of repeats.
+// Fatal entries are never held.
+type Dedup struct {
+	window           time.Duration
+	contextExtractor ContextExtractor
+
+	mu      sync.Mutex
+	pending map[string]*pendingEntry
+	closed  bool
+}
+
+// NewDedup creates a Dedup with the given window.
+// Context fields are extracted with DefaultContextExtractor.
+func NewDedup(window time.Duration) *Dedup {
+	return &Dedup{
+		window:           window,
+		contextExtractor: DefaultContextExtractor(),
+		pending:          make(map[string]*pendingEntry),
+	}
+}
+
+// Wrap returns a Logger that deduplicates entries before passing them to next
+func (d *Dedup) Wrap(next Logger) Logger {
+	return &dedupLogger{
+		next:  next,
+		dedup: d,
+	}
+}
+
+// Flush writes the held repeats immediately and ends every window, so the
+// next occurrence of an entry is written straight away
+func (d *Dedup) Flush() {
+	d.mu.Lock()
+	entries := d.takePending()
+	d.mu.Unlock()
+
+	for _, p := range entries {
+		p.emit()
+	}
+}
+
+// Close flushes the held repeats and stops the window timers. Entries logged
+// after Close are written without deduplication.
+func (d *Dedup) Close() error {
+	d.mu.Lock()
+	d.closed = true
+	entries := d.takePending()
+	d.mu.Unlock()
+
+	for _, p := range entries {
+		p.emit()
+	}
+	return nil
+}
+
+// takePending stops every window and returns its entries. d.mu must be held.
+func (d *Dedup) takePending() []*pendingEntry {
+	entries := make([]*pendingEntry, 0, len(d.pending))
+	for key, p := range d.pending {
+		p.timer.Stop()
+		entries = append(entries, p)
+		delete(d.pending, key)
+	}
+	return entries
+}
+
+// add records an occurrence of an entry. The first occurrence is written and
+// starts a window; repeats within the window are counted.
+func (d *Dedup) add(next Logger, scope, level, msg string, fields []Field) {
+	key := level + "\x00" + msg + "\x00" + scope + "\x00" + fieldsSignature(fields)
+
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		logAt(next, level, msg, fields)
+		return
+	}
+	if p, ok := d.pending[key]; ok {
+		p.count++
+		d.mu.Unlock()
+		return
+	}
+
+	p := &pendingEntry{
+		logger: next,
+		level:  level,
+		msg:    msg,
+		fields: fields,
+	}
+	p.timer = time.AfterFunc(d.window, func() {
+		d.mu.Lock()
+		if d.pending[key] != p {
+			d.mu.Unlock()
+			return
+		}
+		delete(d.pending, key)
+		d.mu.Unlock()
+		p.emit()
+	})
+	d.pending[key] = p
+	d.mu.Unlock()
+
+	logAt(next, level, msg, fields)
+}
+
+// emit writes the entry with the number of held repeats, if there were any
+func (p *pendingEntry) emit() {
+	if p.count == 0 {
+		return
+	}
+	fields := append(p.fields[:len(p.fields):len(p.fields)], Field{Key: RepeatedFieldKey, Value: p.count})
+	logAt(p.logger, p.level, p.msg, fields)
+}
+
+// logAt writes an entry to l at the given level
+func logAt(l Logger, level, msg string, fields []Field) {
+	switch level {
+	case LevelDebug:
+		l.Debug(msg, fields...)
+	case LevelInfo:
+		l.Info(msg, fields...)
+	case LevelWarn:
+		l.Warn(msg, fields...)
+	case LevelError:
+		l.Error(msg, fields...)
+	case LevelFatal:
+		l.Fatal(msg, fields...)
+	default:
+		l.Info(msg, fields...)
+	}
+}
+
+// fieldsSignature returns a string identifying a set of fields regardless of order
+func fieldsSignature(fields []Field) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = fmt.Sprintf("%s=%v", f.Key, f.Value)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\x1f")
+}
+
+// dedupLogger wraps a Logger and routes entries through a Dedup
+type dedupLogger struct {
+	next  Logger
+	dedup *Dedup
+	// scope identifies the preset fields, so entries from differently
+	// scoped loggers are never collapsed together
+	scope string
+}
+
+// Close closes the Dedup and then the wrapped logger, see Close
+func (l *dedupLogger) Close() error {
+	return errors.Join(l.dedup.Close(), Close(l.next))
+}
+
+func (l *dedupLogger) Debug(msg string, fields ...Field) {
+	l.dedup.add(l.next, l.scope, LevelDebug, msg, fields)
+}
+
+func (l *dedupLogger) Info(msg string, fields ...Field) {
+	l.dedup.add(l.next, l.scope, LevelInfo, msg, fields)
+}
+
+func (l *dedupLogger) Warn(msg string, fields ...Field) {
+	l.dedup.add(l.next, l.scope, LevelWarn, msg, fields)
+}
+
+func (l *dedupLogger) Error(msg string, fields ...Field) {
+	l.dedup.add(l.next, l.scope, LevelError, msg, fields)
+}
+
+func (l *dedupLogger) Fatal(msg string, fields ...Field) {
+	// Held entries are flushed first because Fatal exits
+	l.dedup.Flush()
+	l.next.Fatal(msg, fields...)
+}
+
+func (l *dedupLogger) WithFields(fields ...Field) Logger {
+	return &dedupLogger{
+		next:  l.next.WithFields(fields...),
+		dedup: l.dedup,
+		scope: l.scope + "\x1e" + fieldsSignature(fields),
+	}
+}
+
+func (l *dedupLogger) WithContext(ctx context.Context) Logger {
+	if ctx == nil {
+		return l
+	}
+
+	var fields []Field
+	if l.dedup.contextExtractor != nil {
+		fields = l.dedup.contextExtractor(ctx)
+	}
+
+	return &dedupLogger{
+		next:  l.next.WithContext(ctx),
+		dedup: l.dedup,
+		scope: l.scope + "\x1e" + fieldsSignature(fields),
+	}
+}
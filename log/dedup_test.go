@@ -0,0 +1,177 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	sink := &memorySink{}
+	dedup := NewDedup(time.Hour)
+	logger := dedup.Wrap(WithSinks(NewNop(), sink))
+
+	for i := 0; i < 3; i++ {
+		logger.Error("connection refused", Field{Key: "host", Value: "db1"}, Field{Key: "port", Value: 5432})
+	}
+	logger.Error("connection refused", Field{Key: "port", Value: 5432}, Field{Key: "host", Value: "db1"})
+	logger.Error("connection refused", Field{Key: "host", Value: "db2"})
+	logger.Warn("connection refused", Field{Key: "host", Value: "db2"})
+
+	if entries := sink.Entries(); len(entries) != 3 {
+		t.Fatalf("expected the first occurrences to be written immediately, got %d entries", len(entries))
+	}
+
+	dedup.Flush()
+
+	entries := sink.Entries()
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(entries))
+	}
+	summary := entries[3]
+	if summary.Level != LevelError || len(summary.Fields) != 3 {
+		t.Fatalf("expected a summary of the repeats, got %+v", summary)
+	}
+	last := summary.Fields[len(summary.Fields)-1]
+	if last.Key != RepeatedFieldKey || last.Value != 3 {
+		t.Errorf("expected %s=3, got %v", RepeatedFieldKey, last)
+	}
+
+	// The window ended with Flush, so the entry is written again
+	logger.Warn("connection refused", Field{Key: "host", Value: "db2"})
+	if entries := sink.Entries(); len(entries) != 5 {
+		t.Errorf("expected 5 entries, got %d", len(entries))
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	sink := &memorySink{}
+	dedup := NewDedup(20 * time.Millisecond)
+	logger := dedup.Wrap(WithSinks(NewNop(), sink))
+
+	logger.Info("tick")
+	logger.Info("tick")
+	if entries := sink.Entries(); len(entries) != 1 || len(entries[0].Fields) != 0 {
+		t.Fatalf("expected the first tick to be written as is, got %+v", entries)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sink.Entries()) == 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	entries := sink.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries after the window, got %d", len(entries))
+	}
+	if len(entries[1].Fields) != 1 || entries[1].Fields[0].Value != 1 {
+		t.Errorf("expected %s=1, got %v", RepeatedFieldKey, entries[1].Fields)
+	}
+}
+
+func TestDedupScopes(t *testing.T) {
+	sink := &memorySink{}
+	dedup := NewDedup(time.Hour)
+	logger := dedup.Wrap(WithSinks(NewNop(), sink))
+
+	logger.WithFields(Field{Key: "request", Value: 1}).Info("done")
+	logger.WithFields(Field{Key: "request", Value: 2}).Info("done")
+	logger.WithContext(context.WithValue(context.Background(), "trace_id", "a")).Info("done")
+	logger.WithContext(context.WithValue(context.Background(), "trace_id", "a")).Info("done")
+	dedup.Flush()
+
+	if entries := sink.Entries(); len(entries) != 4 {
+		t.Errorf("expected 3 first occurrences and 1 summary, got %d entries", len(entries))
+	}
+}
+
+func TestDedupClose(t *testing.T) {
+	sink := &memorySink{}
+	dedup := NewDedup(time.Hour)
+	logger := dedup.Wrap(WithSinks(NewNop(), sink))
+
+	logger.Info("tick")
+	logger.Info("tick")
+	if err := Close(logger); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	entries := sink.Entries()
+	if len(entries) != 2 || entries[1].Fields[0].Value != 1 {
+		t.Fatalf("expected the repeats to be flushed on close, got %+v", entries)
+	}
+	if !sink.closed {
+		t.Error("expected the wrapped logger's sinks to be closed")
+	}
+
+	logger.Info("tick")
+	logger.Info("tick")
+	if entries := sink.Entries(); len(entries) != 4 {
+		t.Errorf("expected entries after close to pass through, got %d", len(entries))
+	}
+	if len(dedup.pending) != 0 {
+		t.Errorf("expected no windows after close, got %d", len(dedup.pending))
+	}
+}
+
+// captureStdout redirects os.Stdout to a file until the returned function is
+// called, which restores it and returns the captured lines
+func captureStdout(t *testing.T) func() []string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = f
+	return func() []string {
+		os.Stdout = stdout
+		f.Close()
+		data, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatalf("failed to read output: %v", err)
+		}
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+}
+
+func TestDedupAdapters(t *testing.T) {
+	for _, kind := range []string{KindZap, KindSlog} {
+		t.Run(kind, func(t *testing.T) {
+			stop := captureStdout(t)
+			next, err := New(Config{Kind: kind, Level: LevelDebug, DisableGlobal: true})
+			if err != nil {
+				stop()
+				t.Fatalf("failed to create logger: %v", err)
+			}
+
+			dedup := NewDedup(time.Hour)
+			logger := dedup.Wrap(next)
+			for i := 0; i < 3; i++ {
+				logger.Debug("repeated", Field{Key: "k", Value: "v"})
+			}
+			dedup.Flush()
+			lines := stop()
+
+			if len(lines) != 2 {
+				t.Fatalf("expected 2 lines, got %d: %q", len(lines), lines)
+			}
+			var first, summary map[string]any
+			if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+				t.Fatalf("failed to parse %q: %v", lines[0], err)
+			}
+			if err := json.Unmarshal([]byte(lines[1]), &summary); err != nil {
+				t.Fatalf("failed to parse %q: %v", lines[1], err)
+			}
+			if first["k"] != "v" || first[RepeatedFieldKey] != nil {
+				t.Errorf("expected the first occurrence as is, got %v", first)
+			}
+			if summary["k"] != "v" || summary[RepeatedFieldKey] != float64(2) {
+				t.Errorf("expected %s=2, got %v", RepeatedFieldKey, summary)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package log
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 )
 
 const (
@@ -23,6 +24,11 @@ const (
 	FormatConsole = "console"
 )
 
+const (
+	SinkSyslog   = "syslog"
+	SinkJournald = "journald"
+)
+
 // Logger is the main interface for structured logging
 type Logger interface {
 	Debug(msg string, fields ...Field)
@@ -44,50 +50,71 @@ type Field struct {
 	Value any
 }
 
-var std Logger
+// stdHolder boxes the global Logger so it can be swapped atomically.
+type stdHolder struct {
+	logger Logger
+}
+
+// std is the global logger used by the package-level functions.
+var std atomic.Pointer[stdHolder]
+
+// loadStd returns the global logger, or nil if none has been set.
+func loadStd() Logger {
+	if h := std.Load(); h != nil {
+		return h.logger
+	}
+	return nil
+}
+
+// L returns the global logger. If no global logger has been set, it returns
+// a no-op logger, so the result is always safe to use.
+func L() Logger {
+	if l := loadStd(); l != nil {
+		return l
+	}
+	return nopLogger{}
+}
 
 func Debug(msg string, fields ...Field) {
-	if std != nil {
-		std.Debug(msg, fields...)
+	if l := loadStd(); l != nil {
+		l.Debug(msg, fields...)
 	}
 }
 
 func Info(msg string, fields ...Field) {
-	if std != nil {
-		std.Info(msg, fields...)
+	if l := loadStd(); l != nil {
+		l.Info(msg, fields...)
 	}
 }
 
 func Warn(msg string, fields ...Field) {
-	if std != nil {
-		std.Warn(msg, fields...)
+	if l := loadStd(); l != nil {
+		l.Warn(msg, fields...)
 	}
 }
 
 func Error(msg string, fields ...Field) {
-	if std != nil {
-		std.Error(msg, fields...)
+	if l := loadStd(); l != nil {
+		l.Error(msg, fields...)
 	}
 }
 
 func Fatal(msg string, fields ...Field) {
-	if std != nil {
-		std.Fatal(msg, fields...)
+	if l := loadStd(); l != nil {
+		l.Fatal(msg, fields...)
 	}
 }
 
+// WithFields returns the global logger with preset fields.
+// It returns a no-op logger if no global logger has been set.
 func WithFields(fields ...Field) Logger {
-	if std != nil {
-		return std.WithFields(fields...)
-	}
-	return nil
+	return L().WithFields(fields...)
 }
 
+// WithContext returns the global logger with fields extracted from ctx.
+// It returns a no-op logger if no global logger has been set.
 func WithContext(ctx context.Context) Logger {
-	if std != nil {
-		return std.WithContext(ctx)
-	}
-	return nil
+	return L().WithContext(ctx)
 }
 
 // ContextExtractor extracts fields from a context
@@ -131,9 +158,24 @@ type Config struct {
 	AdditionalFields []Field
 
 	ContextExtractor ContextExtractor
+
+	// DisableGlobal prevents New from installing the logger as the global default
+	DisableGlobal bool
+
+	// Sinks are additional output targets that receive every entry
+	Sinks []SinkConfig
+}
+
+// SinkConfig selects an additional output target for a logger created by New.
+// If the sink's MinLevel is empty, Config.Level is used.
+type SinkConfig struct {
+	Kind     string // SinkSyslog or SinkJournald
+	Syslog   SyslogConfig
+	Journald JournaldConfig
 }
 
-// New creates a logger. First logger becomes the global default.
+// New creates a logger. Unless config.DisableGlobal is set, the first logger
+// created becomes the global default.
 func New(config Config) (Logger, error) {
 	if config.Kind == "" {
 		config.Kind = KindZap
@@ -164,15 +206,73 @@ func New(config Config) (Logger, error) {
 		return nil, err
 	}
 
-	if std == nil {
-		std = log
+	if len(config.Sinks) > 0 {
+		sinks, err := newSinks(config)
+		if err != nil {
+			return nil, err
+		}
+		log = newSinkLogger(log, config.ContextExtractor, sinks...)
+	}
+
+	if !config.DisableGlobal {
+		std.CompareAndSwap(nil, &stdHolder{logger: log})
 	}
 
 	return log, nil
 }
 
+// newSinks creates the sinks selected in config.Sinks
+func newSinks(config Config) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(config.Sinks))
+	closeAll := func() {
+		for _, s := range sinks {
+			s.Close()
+		}
+	}
+
+	for _, sc := range config.Sinks {
+		var sink Sink
+		var err error
+
+		switch sc.Kind {
+		case SinkSyslog:
+			if sc.Syslog.MinLevel == "" {
+				sc.Syslog.MinLevel = config.Level
+			}
+			if sc.Syslog.AppName == "" {
+				sc.Syslog.AppName = config.ServiceName
+			}
+			sink, err = NewSyslogSink(sc.Syslog)
+		case SinkJournald:
+			if sc.Journald.MinLevel == "" {
+				sc.Journald.MinLevel = config.Level
+			}
+			if sc.Journald.Identifier == "" {
+				sc.Journald.Identifier = config.ServiceName
+			}
+			sink, err = NewJournaldSink(sc.Journald)
+		default:
+			err = fmt.Errorf("unsupported sink kind: %s (supported: %s, %s)", sc.Kind, SinkSyslog, SinkJournald)
+		}
+
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, nil
+}
+
+// SetStd replaces the global logger. It is safe for concurrent use.
+// Passing nil clears the global logger.
 func SetStd(l Logger) {
-	std = l
+	if l == nil {
+		std.Store(nil)
+		return
+	}
+	std.Store(&stdHolder{logger: l})
 }
 
 // DefaultConfig returns the default production configuration
@@ -2,6 +2,7 @@ package log
 
 import (
 	"context"
+	"sync"
 	"testing"
 )
 
@@ -171,10 +172,8 @@ func TestNew(t *testing.T) {
 
 func TestSetStd(t *testing.T) {
 	// Save original std
-	originalStd := std
-	defer func() {
-		std = originalStd
-	}()
+	originalStd := loadStd()
+	defer SetStd(originalStd)
 
 	cfg := SimpleConfig()
 	cfg.Kind = KindSlog
@@ -184,17 +183,15 @@ func TestSetStd(t *testing.T) {
 	}
 
 	SetStd(logger)
-	if std != logger {
+	if loadStd() != logger {
 		t.Error("SetStd did not set the global logger")
 	}
 }
 
 func TestGlobalFunctions(t *testing.T) {
 	// Save original std
-	originalStd := std
-	defer func() {
-		std = originalStd
-	}()
+	originalStd := loadStd()
+	defer SetStd(originalStd)
 
 	// Create a test logger
 	cfg := SimpleConfig()
@@ -226,7 +223,7 @@ func TestGlobalFunctions(t *testing.T) {
 
 	// Test with nil std
 	t.Run("nil std", func(t *testing.T) {
-		std = nil
+		SetStd(nil)
 		Debug("debug")
 		Info("info")
 		Warn("warn")
@@ -234,3 +231,81 @@ func TestGlobalFunctions(t *testing.T) {
 		// These should not panic
 	})
 }
+
+func TestL(t *testing.T) {
+	originalStd := loadStd()
+	defer SetStd(originalStd)
+
+	t.Run("unset returns no-op", func(t *testing.T) {
+		SetStd(nil)
+		if L() == nil {
+			t.Fatal("L returned nil")
+		}
+		if WithFields(Field{Key: "test", Value: "value"}) == nil {
+			t.Error("WithFields returned nil")
+		}
+		if WithContext(context.Background()) == nil {
+			t.Error("WithContext returned nil")
+		}
+	})
+
+	t.Run("set returns global", func(t *testing.T) {
+		logger := NewNop()
+		SetStd(logger)
+		if L() != logger {
+			t.Error("L did not return the global logger")
+		}
+	})
+}
+
+func TestNewDisableGlobal(t *testing.T) {
+	originalStd := loadStd()
+	defer SetStd(originalStd)
+
+	SetStd(nil)
+	cfg := SimpleConfig()
+	cfg.Kind = KindSlog
+	cfg.DisableGlobal = true
+	if _, err := New(cfg); err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	if loadStd() != nil {
+		t.Error("New claimed the global logger despite DisableGlobal")
+	}
+
+	cfg.DisableGlobal = false
+	logger, err := New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	if loadStd() != logger {
+		t.Error("New did not claim the unset global logger")
+	}
+
+	if _, err := New(cfg); err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	if loadStd() != logger {
+		t.Error("New replaced an already set global logger")
+	}
+}
+
+func TestGlobalConcurrentAccess(t *testing.T) {
+	originalStd := loadStd()
+	defer SetStd(originalStd)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetStd(NewNop())
+		}()
+		go func() {
+			defer wg.Done()
+			Info("concurrent", Field{Key: "test", Value: "value"})
+			L().WithFields(Field{Key: "test", Value: "value"}).Debug("concurrent")
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,166 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ducminhgd/gao/internal/promtext"
+)
+
+// metricsName is the name of the counter exposed by Metrics
+const metricsName = "log_entries_total"
+
+var allLevels = []string{LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal}
+
+// metricKey identifies a counter by level and logger name
+type metricKey struct {
+	level string
+	name  string
+}
+
+// Metrics counts log entries by level and logger name and exposes them in
+// the Prometheus text exposition format. Entries are counted before they
+// reach the wrapped logger, so counts stay accurate even if output is dropped.
+type Metrics struct {
+	mu       sync.RWMutex
+	counters map[metricKey]*atomic.Uint64
+}
+
+// NewMetrics creates an empty Metrics collector
+func NewMetrics() *Metrics {
+	return &Metrics{
+		counters: make(map[metricKey]*atomic.Uint64),
+	}
+}
+
+// Wrap returns a Logger that counts every entry under the given logger name
+// and then passes it to next.
+func (m *Metrics) Wrap(next Logger, name string) Logger {
+	// Register every level up front so counters are exported from zero
+	for _, level := range allLevels {
+		m.counter(level, name)
+	}
+	return &metricsLogger{
+		next:    next,
+		metrics: m,
+		name:    name,
+	}
+}
+
+// Count returns the number of entries counted for a level and logger name
+func (m *Metrics) Count(level, name string) uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if c, ok := m.counters[metricKey{level: level, name: name}]; ok {
+		return c.Load()
+	}
+	return 0
+}
+
+// counter returns the counter for a level and logger name, creating it if needed
+func (m *Metrics) counter(level, name string) *atomic.Uint64 {
+	key := metricKey{level: level, name: name}
+
+	m.mu.RLock()
+	c, ok := m.counters[key]
+	m.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.counters[key]; ok {
+		return c
+	}
+	c = &atomic.Uint64{}
+	m.counters[key] = c
+	return c
+}
+
+// WritePrometheus writes all counters in the Prometheus text exposition format
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.RLock()
+	keys := make([]metricKey, 0, len(m.counters))
+	values := make(map[metricKey]uint64, len(m.counters))
+	for k, c := range m.counters {
+		keys = append(keys, k)
+		values[k] = c.Load()
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return levelRank(keys[i].level) < levelRank(keys[j].level)
+	})
+
+	bw := bufio.NewWriter(w)
+	promtext.WriteHeader(bw, metricsName, "counter", "Total number of log entries by level and logger.")
+	for _, k := range keys {
+		fmt.Fprintf(bw, "%s{level=\"%s\",logger=\"%s\"} %d\n",
+			metricsName, promtext.EscapeLabelValue(k.level), promtext.EscapeLabelValue(k.name), values[k])
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP implements http.Handler, rendering the counters for a Prometheus scrape
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", promtext.ContentType)
+	_ = m.WritePrometheus(w)
+}
+
+// metricsLogger wraps a Logger and counts entries per level
+type metricsLogger struct {
+	next    Logger
+	metrics *Metrics
+	name    string
+}
+
+func (l *metricsLogger) Debug(msg string, fields ...Field) {
+	l.metrics.counter(LevelDebug, l.name).Add(1)
+	l.next.Debug(msg, fields...)
+}
+
+func (l *metricsLogger) Info(msg string, fields ...Field) {
+	l.metrics.counter(LevelInfo, l.name).Add(1)
+	l.next.Info(msg, fields...)
+}
+
+func (l *metricsLogger) Warn(msg string, fields ...Field) {
+	l.metrics.counter(LevelWarn, l.name).Add(1)
+	l.next.Warn(msg, fields...)
+}
+
+func (l *metricsLogger) Error(msg string, fields ...Field) {
+	l.metrics.counter(LevelError, l.name).Add(1)
+	l.next.Error(msg, fields...)
+}
+
+func (l *metricsLogger) Fatal(msg string, fields ...Field) {
+	l.metrics.counter(LevelFatal, l.name).Add(1)
+	l.next.Fatal(msg, fields...)
+}
+
+func (l *metricsLogger) WithFields(fields ...Field) Logger {
+	return &metricsLogger{
+		next:    l.next.WithFields(fields...),
+		metrics: l.metrics,
+		name:    l.name,
+	}
+}
+
+func (l *metricsLogger) WithContext(ctx context.Context) Logger {
+	return &metricsLogger{
+		next:    l.next.WithContext(ctx),
+		metrics: l.metrics,
+		name:    l.name,
+	}
+}
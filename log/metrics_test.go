@@ -0,0 +1,68 @@
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsCount(t *testing.T) {
+	metrics := NewMetrics()
+	api := metrics.Wrap(NewNop(), "api")
+	worker := metrics.Wrap(NewNop(), "worker")
+
+	api.Info("request")
+	api.WithFields(Field{Key: "k", Value: "v"}).Error("failed")
+	api.Error("failed again")
+	worker.Warn("slow")
+
+	tests := []struct {
+		level    string
+		name     string
+		expected uint64
+	}{
+		{LevelInfo, "api", 1},
+		{LevelError, "api", 2},
+		{LevelDebug, "api", 0},
+		{LevelWarn, "worker", 1},
+		{LevelError, "worker", 0},
+		{LevelInfo, "unknown", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"/"+tt.level, func(t *testing.T) {
+			if got := metrics.Count(tt.level, tt.name); got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMetricsServeHTTP(t *testing.T) {
+	metrics := NewMetrics()
+	logger := metrics.Wrap(NewNop(), `svc"1`)
+	logger.Error("failed")
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+
+	body := rec.Body.String()
+	expected := []string{
+		"# TYPE log_entries_total counter",
+		`log_entries_total{level="debug",logger="svc\"1"} 0`,
+		`log_entries_total{level="error",logger="svc\"1"} 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected body to contain %q, got:\n%s", line, body)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package log
+
+import "context"
+
+// nopLogger is a Logger that discards every entry
+type nopLogger struct{}
+
+// NewNop returns a Logger that discards every entry.
+// Fatal on a no-op logger does not exit the process.
+func NewNop() Logger {
+	return nopLogger{}
+}
+
+func (nopLogger) Debug(msg string, fields ...Field) {}
+
+func (nopLogger) Info(msg string, fields ...Field) {}
+
+func (nopLogger) Warn(msg string, fields ...Field) {}
+
+func (nopLogger) Error(msg string, fields ...Field) {}
+
+func (nopLogger) Fatal(msg string, fields ...Field) {}
+
+func (l nopLogger) WithFields(fields ...Field) Logger {
+	return l
+}
+
+func (l nopLogger) WithContext(ctx context.Context) Logger {
+	return l
+}
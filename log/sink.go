@@ -0,0 +1,184 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// Entry is a single log record delivered to a Sink
+type Entry struct {
+	Time    time.Time
+	Level   string
+	Message string
+	Fields  []Field
+}
+
+// Sink receives log entries in addition to the wrapped logger's output
+type Sink interface {
+	// Write delivers an entry to the sink
+	Write(entry Entry) error
+
+	// Close flushes any buffered entries and releases resources
+	Close() error
+}
+
+// Close closes the sinks of a logger created by New with Config.Sinks or by
+// WithSinks, and the Dedup of a logger returned by Dedup.Wrap. Loggers
+// without either return nil.
+//
+// Parameters:
+// - l: the logger to close.
+//
+// Returns:
+// - error: the errors returned while closing, joined.
+func Close(l Logger) error {
+	if c, ok := l.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// levelRank orders levels from LevelDebug (lowest) to LevelFatal (highest)
+func levelRank(level string) int {
+	switch level {
+	case LevelDebug:
+		return 0
+	case LevelInfo:
+		return 1
+	case LevelWarn:
+		return 2
+	case LevelError:
+		return 3
+	case LevelFatal:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// levelEnabled reports whether level is at or above minLevel.
+// An empty minLevel enables every level.
+func levelEnabled(level, minLevel string) bool {
+	if minLevel == "" {
+		return true
+	}
+	return levelRank(level) >= levelRank(minLevel)
+}
+
+// sinkLogger wraps a Logger and copies every entry to a set of sinks
+type sinkLogger struct {
+	next             Logger
+	sinks            []Sink
+	fields           []Field
+	contextExtractor ContextExtractor
+}
+
+// WithSinks returns a Logger that logs through next and also writes every
+// entry, including preset fields, to the given sinks.
+// Context fields are extracted with DefaultContextExtractor.
+func WithSinks(next Logger, sinks ...Sink) Logger {
+	return newSinkLogger(next, DefaultContextExtractor(), sinks...)
+}
+
+// newSinkLogger creates a sinkLogger with a custom context extractor
+func newSinkLogger(next Logger, extractor ContextExtractor, sinks ...Sink) Logger {
+	return &sinkLogger{
+		next:             next,
+		sinks:            sinks,
+		contextExtractor: extractor,
+	}
+}
+
+// write sends an entry to every sink. Sink errors are ignored so that a
+// failing sink never interrupts the primary log output.
+func (l *sinkLogger) write(level, msg string, fields []Field) {
+	all := make([]Field, 0, len(l.fields)+len(fields))
+	all = append(all, l.fields...)
+	all = append(all, fields...)
+
+	entry := Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+		Fields:  all,
+	}
+	for _, s := range l.sinks {
+		_ = s.Write(entry)
+	}
+}
+
+// Close closes every sink. The sinks are shared with the loggers derived by
+// WithFields and WithContext, which stop writing to them as well.
+func (l *sinkLogger) Close() error {
+	var errs []error
+	for _, s := range l.sinks {
+		errs = append(errs, s.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (l *sinkLogger) Debug(msg string, fields ...Field) {
+	l.next.Debug(msg, fields...)
+	l.write(LevelDebug, msg, fields)
+}
+
+func (l *sinkLogger) Info(msg string, fields ...Field) {
+	l.next.Info(msg, fields...)
+	l.write(LevelInfo, msg, fields)
+}
+
+func (l *sinkLogger) Warn(msg string, fields ...Field) {
+	l.next.Warn(msg, fields...)
+	l.write(LevelWarn, msg, fields)
+}
+
+func (l *sinkLogger) Error(msg string, fields ...Field) {
+	l.next.Error(msg, fields...)
+	l.write(LevelError, msg, fields)
+}
+
+func (l *sinkLogger) Fatal(msg string, fields ...Field) {
+	// Sinks are written and closed first because the wrapped Fatal exits
+	l.write(LevelFatal, msg, fields)
+	for _, s := range l.sinks {
+		_ = s.Close()
+	}
+	l.next.Fatal(msg, fields...)
+}
+
+func (l *sinkLogger) WithFields(fields ...Field) Logger {
+	preset := make([]Field, 0, len(l.fields)+len(fields))
+	preset = append(preset, l.fields...)
+	preset = append(preset, fields...)
+
+	return &sinkLogger{
+		next:             l.next.WithFields(fields...),
+		sinks:            l.sinks,
+		fields:           preset,
+		contextExtractor: l.contextExtractor,
+	}
+}
+
+func (l *sinkLogger) WithContext(ctx context.Context) Logger {
+	if ctx == nil {
+		return l
+	}
+
+	var fields []Field
+	if l.contextExtractor != nil {
+		fields = l.contextExtractor(ctx)
+	}
+
+	preset := make([]Field, 0, len(l.fields)+len(fields))
+	preset = append(preset, l.fields...)
+	preset = append(preset, fields...)
+
+	return &sinkLogger{
+		next:             l.next.WithContext(ctx),
+		sinks:            l.sinks,
+		fields:           preset,
+		contextExtractor: l.contextExtractor,
+	}
+}
@@ -0,0 +1,271 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrSinkClosed is returned when writing to a sink that has been closed
+var ErrSinkClosed = errors.New("log: sink is closed")
+
+// LogRecord is the row persisted by GORMSink
+type LogRecord struct {
+	ID        uint      `gorm:"primaryKey"`
+	Timestamp time.Time `gorm:"index"`
+	Level     string    `gorm:"size:16;index"`
+	Message   string
+	Fields    string
+}
+
+// GORMSinkConfig configures a GORMSink
+type GORMSinkConfig struct {
+	// Table is the table the entries are inserted into (default "log_entries")
+	Table string
+	// Schema optionally qualifies Table, e.g. "audit" for "audit.log_entries"
+	Schema string
+	// AutoMigrate creates the table on start if it does not exist
+	AutoMigrate bool
+
+	// MinLevel is the lowest level persisted (empty persists every level)
+	MinLevel string
+	// Filter selects the entries to persist, after MinLevel is applied (optional)
+	Filter func(entry Entry) bool
+
+	// BatchSize is the maximum number of entries per insert (default 100)
+	BatchSize int
+	// FlushInterval is how often a partial batch is inserted (default 1s)
+	FlushInterval time.Duration
+	// BufferSize is the number of entries queued before back-pressure applies (default 1000)
+	BufferSize int
+	// Block makes Write wait for buffer space instead of dropping the entry
+	Block bool
+
+	// MaxRetries is the number of retries for a failed insert (default 3, negative disables retries)
+	MaxRetries int
+	// RetryBackoff is the initial delay between retries, doubled after each one (default 100ms)
+	RetryBackoff time.Duration
+	// OnError is called with a batch that could not be inserted after all retries.
+	// If nil, the error is written to stderr.
+	OnError func(err error, entries []Entry)
+}
+
+// GORMSink batches log entries and inserts them into a database table.
+// Each batch is inserted in its own transaction.
+type GORMSink struct {
+	db     *gorm.DB
+	config GORMSinkConfig
+	table  string
+
+	entries chan Entry
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewGORMSink creates a GORMSink writing through db, typically obtained from
+// db.GORMManager.DB(), and starts its background writer.
+//
+// Parameters:
+// - db: the *gorm.DB used to insert entries.
+// - config: the GORMSinkConfig for the sink.
+//
+// Returns:
+// - *GORMSink: the newly created sink.
+// - error: an error if the table migration fails.
+func NewGORMSink(db *gorm.DB, config GORMSinkConfig) (*GORMSink, error) {
+	if db == nil {
+		return nil, errors.New("log: gorm sink requires a non-nil *gorm.DB")
+	}
+	if config.Table == "" {
+		config.Table = "log_entries"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 1000
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 100 * time.Millisecond
+	}
+
+	table := config.Table
+	if config.Schema != "" {
+		table = config.Schema + "." + config.Table
+	}
+
+	if config.AutoMigrate {
+		if err := db.Table(table).AutoMigrate(&LogRecord{}); err != nil {
+			return nil, fmt.Errorf("log: failed to migrate table %s: %w", table, err)
+		}
+	}
+
+	s := &GORMSink{
+		db:      db,
+		config:  config,
+		table:   table,
+		entries: make(chan Entry, config.BufferSize),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+
+	return s, nil
+}
+
+// Write queues an entry for insertion. When the buffer is full, the entry is
+// dropped unless the sink was configured with Block.
+func (s *GORMSink) Write(entry Entry) error {
+	if !levelEnabled(entry.Level, s.config.MinLevel) {
+		return nil
+	}
+	if s.config.Filter != nil && !s.config.Filter(entry) {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrSinkClosed
+	}
+
+	if s.config.Block {
+		s.entries <- entry
+		return nil
+	}
+
+	select {
+	case s.entries <- entry:
+	default:
+		s.dropped.Add(1)
+	}
+	return nil
+}
+
+// Dropped returns the number of entries discarded because the buffer was full
+func (s *GORMSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops accepting entries, inserts everything still buffered and waits
+// for the background writer to finish. It is safe to call more than once.
+func (s *GORMSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.entries)
+	}
+	s.mu.Unlock()
+
+	<-s.done
+	return nil
+}
+
+// run collects entries into batches and flushes them on size or interval
+func (s *GORMSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Entry, 0, s.config.BatchSize)
+	for {
+		select {
+		case entry, ok := <-s.entries:
+			if !ok {
+				s.flush(batch)
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= s.config.BatchSize {
+				s.flush(batch)
+				batch = make([]Entry, 0, s.config.BatchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.flush(batch)
+				batch = make([]Entry, 0, s.config.BatchSize)
+			}
+		}
+	}
+}
+
+// flush inserts a batch, retrying with exponential backoff
+func (s *GORMSink) flush(batch []Entry) {
+	if len(batch) == 0 {
+		return
+	}
+
+	records := make([]LogRecord, len(batch))
+	for i, entry := range batch {
+		records[i] = toLogRecord(entry)
+	}
+
+	var err error
+	backoff := s.config.RetryBackoff
+	for attempt := 0; attempt <= s.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			return tx.Table(s.table).Create(&records).Error
+		})
+		if err == nil {
+			return
+		}
+		// Clear IDs possibly assigned by the failed insert before retrying
+		for i := range records {
+			records[i].ID = 0
+		}
+	}
+
+	if s.config.OnError != nil {
+		s.config.OnError(err, batch)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "log: failed to insert %d entries into %s: %v\n", len(batch), s.table, err)
+}
+
+// toLogRecord converts an Entry to a LogRecord, encoding fields as JSON
+func toLogRecord(entry Entry) LogRecord {
+	record := LogRecord{
+		Timestamp: entry.Time,
+		Level:     entry.Level,
+		Message:   entry.Message,
+	}
+
+	if len(entry.Fields) > 0 {
+		m := make(map[string]any, len(entry.Fields))
+		for _, f := range entry.Fields {
+			if err, ok := f.Value.(error); ok {
+				m[f.Key] = err.Error()
+				continue
+			}
+			m[f.Key] = f.Value
+		}
+		if b, err := json.Marshal(m); err == nil {
+			record.Fields = string(b)
+		} else {
+			record.Fields = fmt.Sprintf("%v", m)
+		}
+	}
+
+	return record
+}
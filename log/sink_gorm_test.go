@@ -0,0 +1,178 @@
+package log_test
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gao/db"
+	"github.com/ducminhgd/gao/log"
+)
+
+// newSQLiteManager opens a file-backed SQLite database through db.GORMManager
+func newSQLiteManager(t *testing.T) *db.GORMManager {
+	t.Helper()
+	if !slices.Contains(db.SupportedTypes(), db.SQLite) {
+		t.Skip("SQLite support not compiled in")
+	}
+	manager, err := db.NewGORMManagerFromConfig(db.NewSimpleManagerConfig(db.DBConfig{
+		Type:       db.SQLite,
+		Database:   filepath.Join(t.TempDir(), "logs.db"),
+		PoolConfig: db.DefaultPoolConfig(),
+	}, nil))
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	t.Cleanup(func() { manager.Close() })
+	return manager
+}
+
+func countRecords(t *testing.T, manager *db.GORMManager, table string) int64 {
+	t.Helper()
+	var count int64
+	if err := manager.DB().Table(table).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count records: %v", err)
+	}
+	return count
+}
+
+func TestGORMSink(t *testing.T) {
+	manager := newSQLiteManager(t)
+
+	sink, err := log.NewGORMSink(manager.DB(), log.GORMSinkConfig{
+		Table:         "audit_logs",
+		AutoMigrate:   true,
+		MinLevel:      log.LevelInfo,
+		BatchSize:     2,
+		FlushInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+
+	logger := log.WithSinks(log.NewNop(), sink).WithFields(log.Field{Key: "service", Value: "test"})
+	logger.Debug("not persisted")
+	logger.Info("user login", log.Field{Key: "user_id", Value: 42})
+	logger.Warn("slow query")
+	logger.Error("failed", log.Field{Key: "error", Value: errors.New("boom")})
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close sink: %v", err)
+	}
+
+	var records []log.LogRecord
+	if err := manager.DB().Table("audit_logs").Order("id").Find(&records).Error; err != nil {
+		t.Fatalf("failed to query records: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+	if records[0].Message != "user login" || records[0].Level != log.LevelInfo {
+		t.Errorf("unexpected first record: %+v", records[0])
+	}
+	if records[0].Fields != `{"service":"test","user_id":42}` {
+		t.Errorf("unexpected fields: %s", records[0].Fields)
+	}
+	if records[2].Fields != `{"error":"boom","service":"test"}` {
+		t.Errorf("unexpected fields: %s", records[2].Fields)
+	}
+
+	if err := sink.Write(log.Entry{Level: log.LevelInfo}); !errors.Is(err, log.ErrSinkClosed) {
+		t.Errorf("expected ErrSinkClosed, got %v", err)
+	}
+}
+
+func TestGORMSinkFilter(t *testing.T) {
+	manager := newSQLiteManager(t)
+
+	sink, err := log.NewGORMSink(manager.DB(), log.GORMSinkConfig{
+		AutoMigrate: true,
+		Filter: func(entry log.Entry) bool {
+			for _, f := range entry.Fields {
+				if f.Key == "audit" {
+					return true
+				}
+			}
+			return false
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+
+	logger := log.WithSinks(log.NewNop(), sink)
+	logger.Info("ignored")
+	logger.Info("kept", log.Field{Key: "audit", Value: true})
+	sink.Close()
+
+	if count := countRecords(t, manager, "log_entries"); count != 1 {
+		t.Errorf("expected 1 record, got %d", count)
+	}
+}
+
+func TestGORMSinkBackPressure(t *testing.T) {
+	manager := newSQLiteManager(t)
+
+	sink, err := log.NewGORMSink(manager.DB(), log.GORMSinkConfig{
+		AutoMigrate:   true,
+		BufferSize:    1,
+		BatchSize:     1000,
+		FlushInterval: time.Hour,
+		Block:         true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				sink.Write(log.Entry{Time: time.Now(), Level: log.LevelInfo, Message: "blocking"})
+			}
+		}()
+	}
+	wg.Wait()
+	sink.Close()
+
+	if sink.Dropped() != 0 {
+		t.Errorf("expected no dropped entries, got %d", sink.Dropped())
+	}
+	if count := countRecords(t, manager, "log_entries"); count != 100 {
+		t.Errorf("expected 100 records, got %d", count)
+	}
+}
+
+func TestGORMSinkRetry(t *testing.T) {
+	manager := newSQLiteManager(t)
+
+	var mu sync.Mutex
+	var failed []log.Entry
+	sink, err := log.NewGORMSink(manager.DB(), log.GORMSinkConfig{
+		Table:        "missing_table",
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+		OnError: func(err error, entries []log.Entry) {
+			mu.Lock()
+			defer mu.Unlock()
+			failed = append(failed, entries...)
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+
+	sink.Write(log.Entry{Time: time.Now(), Level: log.LevelError, Message: "lost"})
+	sink.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(failed) != 1 || failed[0].Message != "lost" {
+		t.Errorf("expected OnError to receive the failed entry, got %+v", failed)
+	}
+}
@@ -0,0 +1,141 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultJournaldSocket is the systemd-journald native protocol socket
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// JournaldConfig configures a JournaldSink
+type JournaldConfig struct {
+	// SocketPath is the journald socket (default DefaultJournaldSocket)
+	SocketPath string
+	// Identifier is the SYSLOG_IDENTIFIER field (default: the executable name)
+	Identifier string
+	// MinLevel is the lowest level sent (empty sends every level)
+	MinLevel string
+}
+
+// JournaldSink writes entries to systemd-journald using its native protocol.
+// Field keys are upper-cased and restricted to A-Z, 0-9 and '_'. Keys that
+// collide with the fields set by the sink (MESSAGE, PRIORITY and
+// SYSLOG_IDENTIFIER) are prefixed with "FIELD_".
+// Entries larger than the socket's datagram limit are rejected by the kernel.
+type JournaldSink struct {
+	config JournaldConfig
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+// NewJournaldSink creates a JournaldSink bound to the journald socket
+//
+// Parameters:
+// - config: the JournaldConfig for the sink.
+//
+// Returns:
+// - *JournaldSink: the newly created sink.
+// - error: an error if the socket cannot be opened.
+func NewJournaldSink(config JournaldConfig) (*JournaldSink, error) {
+	if config.SocketPath == "" {
+		config.SocketPath = DefaultJournaldSocket
+	}
+	if config.Identifier == "" {
+		config.Identifier = filepath.Base(os.Args[0])
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: config.SocketPath, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("log: failed to connect to journald %s: %w", config.SocketPath, err)
+	}
+
+	return &JournaldSink{
+		config: config,
+		conn:   conn,
+	}, nil
+}
+
+// Write sends an entry to journald
+func (s *JournaldSink) Write(entry Entry) error {
+	if !levelEnabled(entry.Level, s.config.MinLevel) {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", entry.Message)
+	writeJournalField(&buf, "PRIORITY", fmt.Sprintf("%d", syslogSeverity(entry.Level)))
+	writeJournalField(&buf, "SYSLOG_IDENTIFIER", s.config.Identifier)
+	for _, f := range entry.Fields {
+		key := journalFieldName(f.Key)
+		if key == "" {
+			continue
+		}
+		if journalReservedFields[key] {
+			key = "FIELD_" + key
+		}
+		writeJournalField(&buf, key, fmt.Sprintf("%v", f.Value))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return ErrSinkClosed
+	}
+	_, err := s.conn.Write(buf.Bytes())
+	return err
+}
+
+// Close closes the journald socket
+func (s *JournaldSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// journalReservedFields are the fields written by JournaldSink itself
+var journalReservedFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+}
+
+// writeJournalField encodes a field using the simple KEY=value form, or the
+// length-prefixed binary form when the value contains a newline
+func writeJournalField(buf *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(key + "=" + value + "\n")
+		return
+	}
+	buf.WriteString(key + "\n")
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value + "\n")
+}
+
+// journalFieldName converts a field key to a valid journal field name.
+// Names may not start with '_' (reserved for trusted fields) or a digit.
+func journalFieldName(key string) string {
+	b := []byte(strings.ToUpper(key))
+	for i, c := range b {
+		if !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	name := strings.TrimLeft(string(b), "_0123456789")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
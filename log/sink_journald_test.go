@@ -0,0 +1,68 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournaldSink(t *testing.T) {
+	path := filepath.Join(shortTempDir(t), "journal.sock")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := NewJournaldSink(JournaldConfig{SocketPath: path, Identifier: "svc", MinLevel: LevelInfo})
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	defer sink.Close()
+
+	sink.Write(Entry{Level: LevelDebug, Message: "skipped"})
+	sink.Write(Entry{
+		Level:   LevelWarn,
+		Message: "line1\nline2",
+		Fields:  []Field{{Key: "request-id", Value: "abc"}, {Key: "_hidden", Value: 1}, {Key: "123", Value: 2}, {Key: "priority", Value: "high"}, {Key: "message", Value: "m"}},
+	})
+
+	var expected bytes.Buffer
+	expected.WriteString("MESSAGE\n")
+	binary.Write(&expected, binary.LittleEndian, uint64(len("line1\nline2")))
+	expected.WriteString("line1\nline2\n")
+	expected.WriteString("PRIORITY=4\n")
+	expected.WriteString("SYSLOG_IDENTIFIER=svc\n")
+	expected.WriteString("REQUEST_ID=abc\n")
+	expected.WriteString("HIDDEN=1\n")
+	expected.WriteString("FIELD_PRIORITY=high\n")
+	expected.WriteString("FIELD_MESSAGE=m\n")
+
+	if got := readPacket(t, conn); got != expected.String() {
+		t.Errorf("expected %q, got %q", expected.String(), got)
+	}
+}
+
+func TestJournalFieldName(t *testing.T) {
+	tests := map[string]string{
+		"user_id":    "USER_ID",
+		"request-id": "REQUEST_ID",
+		"_private":   "PRIVATE",
+		"1st":        "ST",
+		"":           "",
+	}
+	for key, expected := range tests {
+		if got := journalFieldName(key); got != expected {
+			t.Errorf("%q: expected %q, got %q", key, expected, got)
+		}
+	}
+}
+
+func TestNewJournaldSinkMissingSocket(t *testing.T) {
+	path := filepath.Join(shortTempDir(t), "missing.sock")
+	if _, err := NewJournaldSink(JournaldConfig{SocketPath: path}); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
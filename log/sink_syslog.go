@@ -0,0 +1,270 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Facility is a syslog facility. The zero value, FacilityDefault, selects
+// FacilityUser; the other constants map to the RFC 5424 facility codes.
+type Facility int
+
+// Syslog facilities as defined in RFC 5424. Each constant holds its facility
+// code plus one so that the zero value can mean "unset"; use Code for the
+// numeric RFC 5424 value.
+const (
+	FacilityDefault Facility = 0
+	FacilityKern    Facility = 0 + 1
+	FacilityUser    Facility = 1 + 1
+	FacilityDaemon  Facility = 3 + 1
+	FacilityAuth    Facility = 4 + 1
+	FacilityLocal0  Facility = 16 + 1
+	FacilityLocal1  Facility = 17 + 1
+	FacilityLocal2  Facility = 18 + 1
+	FacilityLocal3  Facility = 19 + 1
+	FacilityLocal4  Facility = 20 + 1
+	FacilityLocal5  Facility = 21 + 1
+	FacilityLocal6  Facility = 22 + 1
+	FacilityLocal7  Facility = 23 + 1
+)
+
+// Code returns the RFC 5424 facility code (FacilityDefault reports FacilityUser's code)
+func (f Facility) Code() int {
+	if f == FacilityDefault {
+		f = FacilityUser
+	}
+	return int(f) - 1
+}
+
+// syslogStructuredDataID is the SD-ID carrying entry fields (private enterprise number 32473 is reserved for examples)
+const syslogStructuredDataID = "fields@32473"
+
+// SyslogConfig configures a SyslogSink
+type SyslogConfig struct {
+	// Network is "udp", "tcp", "unix" (stream socket) or "unixgram" (datagram socket, e.g. /dev/log)
+	Network string
+	// Address is the host:port or socket path of the syslog server
+	Address string
+	// Facility is the syslog facility (default: FacilityUser)
+	Facility Facility
+	// AppName is the APP-NAME header field (default: the executable name)
+	AppName string
+	// Hostname is the HOSTNAME header field (default: os.Hostname)
+	Hostname string
+	// MinLevel is the lowest level sent (empty sends every level)
+	MinLevel string
+}
+
+// SyslogSink writes entries as RFC 5424 messages to a syslog server.
+// Stream transports use octet-counting framing (RFC 6587) and reconnect once
+// on write failure.
+type SyslogSink struct {
+	config   SyslogConfig
+	facility int
+	stream   bool
+	pid      string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+// NewSyslogSink creates a SyslogSink and connects to the server
+//
+// Parameters:
+// - config: the SyslogConfig for the sink.
+//
+// Returns:
+// - *SyslogSink: the newly created sink.
+// - error: an error if the network is unsupported or the connection fails.
+func NewSyslogSink(config SyslogConfig) (*SyslogSink, error) {
+	var stream bool
+	switch config.Network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		stream = true
+	case "udp", "udp4", "udp6", "unixgram":
+		stream = false
+	default:
+		return nil, fmt.Errorf("unsupported syslog network: %s (supported: udp, tcp, unix, unixgram)", config.Network)
+	}
+	if config.Address == "" {
+		return nil, errors.New("log: syslog address is empty")
+	}
+	if config.Facility < FacilityDefault || config.Facility > FacilityLocal7 {
+		return nil, fmt.Errorf("log: invalid syslog facility: %d", config.Facility.Code())
+	}
+	if config.AppName == "" {
+		config.AppName = filepath.Base(os.Args[0])
+	}
+	if config.Hostname == "" {
+		config.Hostname, _ = os.Hostname()
+	}
+
+	s := &SyslogSink{
+		config:   config,
+		facility: config.Facility.Code(),
+		stream:   stream,
+		pid:      strconv.Itoa(os.Getpid()),
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// connect dials the syslog server, replacing any existing connection
+func (s *SyslogSink) connect() error {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	conn, err := net.DialTimeout(s.config.Network, s.config.Address, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("log: failed to connect to syslog %s %s: %w", s.config.Network, s.config.Address, err)
+	}
+	s.conn = conn
+	return nil
+}
+
+// Write sends an entry to the syslog server
+func (s *SyslogSink) Write(entry Entry) error {
+	if !levelEnabled(entry.Level, s.config.MinLevel) {
+		return nil
+	}
+
+	msg := s.format(entry)
+	if s.stream {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSinkClosed
+	}
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	if _, err := s.conn.Write([]byte(msg)); err != nil {
+		if !s.stream {
+			return err
+		}
+		// Stream connections may have been closed by the server; retry once
+		if err := s.connect(); err != nil {
+			return err
+		}
+		_, err = s.conn.Write([]byte(msg))
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection to the syslog server. Writes after Close
+// return ErrSinkClosed.
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// format renders an entry as an RFC 5424 message:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (s *SyslogSink) format(entry Entry) string {
+	ts := entry.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s - ",
+		s.facility*8+syslogSeverity(entry.Level),
+		ts.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderField(s.config.Hostname, 255),
+		syslogHeaderField(s.config.AppName, 48),
+		s.pid,
+	)
+
+	if len(entry.Fields) == 0 {
+		b.WriteString("-")
+	} else {
+		b.WriteString("[" + syslogStructuredDataID)
+		for _, f := range entry.Fields {
+			fmt.Fprintf(&b, " %s=\"%s\"", syslogParamName(f.Key), syslogParamValue(fmt.Sprintf("%v", f.Value)))
+		}
+		b.WriteString("]")
+	}
+
+	if entry.Message != "" {
+		b.WriteString(" " + entry.Message)
+	}
+	return b.String()
+}
+
+// syslogSeverity maps our levels to RFC 5424 severities
+func syslogSeverity(level string) int {
+	switch level {
+	case LevelDebug:
+		return 7 // debug
+	case LevelInfo:
+		return 6 // informational
+	case LevelWarn:
+		return 4 // warning
+	case LevelError:
+		return 3 // error
+	case LevelFatal:
+		return 2 // critical
+	default:
+		return 6
+	}
+}
+
+// syslogHeaderField restricts a header field to printable US-ASCII without spaces
+func syslogHeaderField(v string, maxLen int) string {
+	if v == "" {
+		return "-"
+	}
+	b := []byte(v)
+	for i, c := range b {
+		if c < 33 || c > 126 {
+			b[i] = '_'
+		}
+	}
+	if len(b) > maxLen {
+		b = b[:maxLen]
+	}
+	return string(b)
+}
+
+// syslogParamName restricts an SD-PARAM name to the allowed characters
+func syslogParamName(v string) string {
+	b := []byte(syslogHeaderField(v, 32))
+	for i, c := range b {
+		if c == '=' || c == ']' || c == '"' {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// syslogParamValueReplacer escapes the characters RFC 5424 requires in PARAM-VALUE
+var syslogParamValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func syslogParamValue(v string) string {
+	return syslogParamValueReplacer.Replace(v)
+}
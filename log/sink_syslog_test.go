@@ -0,0 +1,261 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// shortTempDir returns a temporary directory short enough for unix socket paths
+func shortTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "gao")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func readPacket(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("failed to read packet: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestSyslogSinkUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := NewSyslogSink(SyslogConfig{
+		Network:  "udp",
+		Address:  conn.LocalAddr().String(),
+		Facility: FacilityLocal0,
+		AppName:  "my app",
+		Hostname: "host1",
+		MinLevel: LevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	defer sink.Close()
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	sink.Write(Entry{Time: ts, Level: LevelDebug, Message: "skipped"})
+	sink.Write(Entry{
+		Time:    ts,
+		Level:   LevelError,
+		Message: "disk full",
+		Fields:  []Field{{Key: "path", Value: `/var/"data"]`}, {Key: "free", Value: 0}},
+	})
+
+	// local0 (16) * 8 + error (3) = 131
+	expected := `<131>1 2024-01-02T03:04:05.000006Z host1 my_app ` + sink.pid +
+		` - [fields@32473 path="/var/\"data\"\]" free="0"] disk full`
+	if got := readPacket(t, conn); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestSyslogSinkTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			var n int
+			if _, err := fmt.Fscanf(r, "%d ", &n); err != nil {
+				return
+			}
+			buf := make([]byte, n)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+			received <- string(buf)
+		}
+	}()
+
+	sink, err := NewSyslogSink(SyslogConfig{Network: "tcp", Address: ln.Addr().String(), Hostname: "h", AppName: "a"})
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	defer sink.Close()
+
+	sink.Write(Entry{Level: LevelWarn, Message: "first"})
+	sink.Write(Entry{Level: LevelInfo, Message: "second"})
+
+	for _, want := range []string{"<12>1 ", "<14>1 "} {
+		select {
+		case msg := <-received:
+			if !strings.HasPrefix(msg, want) {
+				t.Errorf("expected prefix %q, got %q", want, msg)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for message")
+		}
+	}
+}
+
+func TestSyslogSinkUnixgram(t *testing.T) {
+	path := filepath.Join(shortTempDir(t), "syslog.sock")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := NewSyslogSink(SyslogConfig{Network: "unixgram", Address: path, Hostname: "h", AppName: "a"})
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	defer sink.Close()
+
+	sink.Write(Entry{Level: LevelFatal, Message: "bye"})
+	if got := readPacket(t, conn); !strings.HasPrefix(got, "<10>1 ") || !strings.HasSuffix(got, " - - bye") {
+		t.Errorf("unexpected message: %q", got)
+	}
+}
+
+func TestSyslogSinkFacility(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	for _, tt := range []struct {
+		facility Facility
+		prefix   string
+	}{
+		{FacilityDefault, "<14>1 "},
+		{FacilityKern, "<6>1 "},
+		{FacilityLocal7, "<190>1 "},
+	} {
+		sink, err := NewSyslogSink(SyslogConfig{Network: "udp", Address: conn.LocalAddr().String(), Facility: tt.facility})
+		if err != nil {
+			t.Fatalf("failed to create sink: %v", err)
+		}
+		sink.Write(Entry{Level: LevelInfo, Message: "hello"})
+		sink.Close()
+		if got := readPacket(t, conn); !strings.HasPrefix(got, tt.prefix) {
+			t.Errorf("expected prefix %q, got %q", tt.prefix, got)
+		}
+	}
+
+	for _, invalid := range []Facility{-1, FacilityLocal7 + 1} {
+		if _, err := NewSyslogSink(SyslogConfig{Network: "udp", Address: conn.LocalAddr().String(), Facility: invalid}); err == nil {
+			t.Errorf("expected error for invalid facility %d, got nil", invalid)
+		}
+	}
+}
+
+func TestSyslogSinkClosed(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := NewSyslogSink(SyslogConfig{Network: "udp", Address: conn.LocalAddr().String()})
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close sink: %v", err)
+	}
+	if err := sink.Write(Entry{Level: LevelInfo, Message: "late"}); !errors.Is(err, ErrSinkClosed) {
+		t.Errorf("expected ErrSinkClosed, got %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Errorf("expected second close to succeed, got %v", err)
+	}
+}
+
+func TestSyslogSinkUnsupportedNetwork(t *testing.T) {
+	if _, err := NewSyslogSink(SyslogConfig{Network: "sctp", Address: "localhost:514"}); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	tests := map[string]int{
+		LevelDebug: 7,
+		LevelInfo:  6,
+		LevelWarn:  4,
+		LevelError: 3,
+		LevelFatal: 2,
+	}
+	for level, expected := range tests {
+		if got := syslogSeverity(level); got != expected {
+			t.Errorf("%s: expected %d, got %d", level, expected, got)
+		}
+	}
+}
+
+func TestNewWithSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	logger, err := New(Config{
+		Kind:          KindSlog,
+		Level:         LevelWarn,
+		ServiceName:   "svc",
+		DisableGlobal: true,
+		Sinks: []SinkConfig{
+			{Kind: SinkSyslog, Syslog: SyslogConfig{Network: "udp", Address: conn.LocalAddr().String()}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	logger.Info("below level")
+	logger.Warn("sent")
+	if got := readPacket(t, conn); !strings.Contains(got, " svc ") || !strings.HasSuffix(got, " sent") {
+		t.Errorf("unexpected message: %q", got)
+	}
+
+	if err := Close(logger); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+	logger.Warn("after close")
+	conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if n, _, err := conn.ReadFrom(make([]byte, 1024)); err == nil {
+		t.Errorf("expected no message after close, got %d bytes", n)
+	}
+	if err := Close(NewNop()); err != nil {
+		t.Errorf("expected nil for a logger without sinks, got %v", err)
+	}
+
+	if _, err := New(Config{DisableGlobal: true, Sinks: []SinkConfig{{Kind: "invalid"}}}); err == nil {
+		t.Error("expected error for invalid sink kind, got nil")
+	}
+}
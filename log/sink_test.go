@@ -0,0 +1,87 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// memorySink records every entry it receives
+type memorySink struct {
+	mu      sync.Mutex
+	entries []Entry
+	closed  bool
+}
+
+func (s *memorySink) Write(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *memorySink) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Entry(nil), s.entries...)
+}
+
+func TestWithSinks(t *testing.T) {
+	sink := &memorySink{}
+	logger := WithSinks(NewNop(), sink)
+
+	logger.Debug("debug")
+	logger.Info("info", Field{Key: "a", Value: 1})
+	logger.WithFields(Field{Key: "preset", Value: "x"}).Warn("warn")
+
+	ctx := context.WithValue(context.Background(), "trace_id", "trace-123")
+	logger.WithContext(ctx).Error("error")
+
+	entries := sink.Entries()
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(entries))
+	}
+
+	expectedLevels := []string{LevelDebug, LevelInfo, LevelWarn, LevelError}
+	for i, level := range expectedLevels {
+		if entries[i].Level != level {
+			t.Errorf("entry %d: expected level %s, got %s", i, level, entries[i].Level)
+		}
+	}
+	if len(entries[2].Fields) != 1 || entries[2].Fields[0].Key != "preset" {
+		t.Errorf("expected preset field, got %v", entries[2].Fields)
+	}
+	if len(entries[3].Fields) != 1 || entries[3].Fields[0].Key != "trace_id" {
+		t.Errorf("expected trace_id field, got %v", entries[3].Fields)
+	}
+}
+
+func TestLevelEnabled(t *testing.T) {
+	tests := []struct {
+		level    string
+		minLevel string
+		expected bool
+	}{
+		{LevelDebug, "", true},
+		{LevelDebug, LevelInfo, false},
+		{LevelInfo, LevelInfo, true},
+		{LevelError, LevelWarn, true},
+		{LevelWarn, LevelError, false},
+		{LevelFatal, LevelError, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level+">="+tt.minLevel, func(t *testing.T) {
+			if got := levelEnabled(tt.level, tt.minLevel); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -1,7 +1,11 @@
 // This package is for pagination
 package pagination
 
-import "math"
+import (
+	"math"
+
+	"github.com/ducminhgd/gao/defaults"
+)
 
 const (
 	// Page size is the number of records in a page
@@ -15,6 +19,23 @@ type PagingOptions struct {
 	PageSize int `json:"pageSize" default:"20"`
 }
 
+// WithDefaults returns the options with zero fields set from their `default` tags,
+// so that options decoded from a request without page or pageSize get page 1 of 20 records.
+// GetPageAndPageSize and GetLimitOffset do not apply the defaults, as they allow a zero
+// pageSize; use LimitOffset for options decoded from a request.
+func (o PagingOptions) WithDefaults() PagingOptions {
+	// The tags are constant and covered by tests, so Apply cannot fail
+	_ = defaults.Apply(&o)
+	return o
+}
+
+// LimitOffset returns the offset and limit of the options, like GetLimitOffset,
+// after setting zero fields with WithDefaults.
+func (o PagingOptions) LimitOffset() (int, int) {
+	o = o.WithDefaults()
+	return GetLimitOffset(o.Page, o.PageSize)
+}
+
 // GetTotalPage calculates the total number of pages needed to display all records.
 // It takes the total number of records and the desired page size as input.
 // The output is the minimum number of pages required to display all records,
@@ -161,3 +161,19 @@ func TestGetLimitOffset(t *testing.T) {
 		})
 	}
 }
+
+func TestPagingOptionsWithDefaults(t *testing.T) {
+	assert.Equal(t, PagingOptions{Page: defaultPage, PageSize: defaultPageSize}, PagingOptions{}.WithDefaults())
+	assert.Equal(t, PagingOptions{Page: 3, PageSize: 50}, PagingOptions{Page: 3, PageSize: 50}.WithDefaults())
+	assert.Equal(t, PagingOptions{Page: 2, PageSize: defaultPageSize}, PagingOptions{Page: 2}.WithDefaults())
+}
+
+func TestPagingOptionsLimitOffset(t *testing.T) {
+	offset, limit := PagingOptions{}.LimitOffset()
+	assert.Equal(t, 0, offset)
+	assert.Equal(t, defaultPageSize, limit)
+
+	offset, limit = PagingOptions{Page: 3, PageSize: 500}.LimitOffset()
+	assert.Equal(t, 2*maxPageSize, offset)
+	assert.Equal(t, maxPageSize, limit)
+}